@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
+	"strings"
+)
+
+var showAliases bool
+
+// tagAlias is an artifact with every tag pointing to its digest.
+type tagAlias struct {
+	digest string
+	size   int64
+	tags   []string
+}
+
+type jsonAlias struct {
+	Digest    string   `json:"digest"`
+	SizeBytes int64    `json:"size_bytes"`
+	Tags      []string `json:"tags"`
+}
+
+// digestAliases groups tags of details by digest in scan order and returns
+// the digests with more than one tag, along with counts of every tag and
+// tagged digest.
+func digestAliases(details []*tagDetail) (aliases []*tagAlias, tags int, digests int) {
+	byDigest := make(map[string]*tagAlias)
+	var order []*tagAlias
+	for _, d := range details {
+		if d.name == "" {
+			continue
+		}
+		tags++
+		a := byDigest[d.digest]
+		if a == nil {
+			a = &tagAlias{digest: d.digest, size: d.size}
+			byDigest[d.digest] = a
+			order = append(order, a)
+		}
+		a.tags = append(a.tags, d.name)
+	}
+	for _, a := range order {
+		if len(a.tags) > 1 {
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases, tags, len(order)
+}
+
+func renderAliases(w io.Writer, res *projectResult) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("%s tag aliases", res.projectName)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Repository", "Digest", "Tags", "Size"})
+	var tags, digests int
+	for _, v := range res.artifacts {
+		aliases, repoTags, repoDigests := digestAliases(v.details)
+		tags += repoTags
+		digests += repoDigests
+		for _, a := range aliases {
+			tw.AppendRow(table.Row{truncateName(displayRepoName(res.projectName, v.repositoryName), maxColWidth), a.digest, strings.Join(a.tags, ", "), humanArtifactSize(a.size)})
+		}
+	}
+	if !noFooter {
+		tw.AppendFooter(table.Row{"Total", "", fmt.Sprintf("%d tags on %d artifacts", tags, digests), ""})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
+	"sort"
+)
+
+var byArch bool
+
+// platformArch returns the architecture with its variant, e.g. arm64/v8.
+func platformArch(arch string, variant string) string {
+	switch {
+	case arch == "":
+		return "unknown"
+	case variant != "":
+		return arch + "/" + variant
+	}
+	return arch
+}
+
+// addArchGroups counts an image in its config architecture, for an index
+// every child is fetched and its size counted in the child platform
+// architecture.
+func addArchGroups(cs *v2client.HarborAPI, ctx context.Context, projectName string, repoName string, groups map[string]*tagGroup, a *models.Artifact) (err error) {
+	if len(a.References) == 0 {
+		arch, _ := a.ExtraAttrs["architecture"].(string)
+		variant, _ := a.ExtraAttrs["variant"].(string)
+		addGroup(groups, platformArch(arch, variant), a)
+		return
+	}
+	for _, r := range a.References {
+		if r == nil || r.ChildDigest == a.Digest {
+			continue
+		}
+		var size int64
+		size, err = referencesSize(cs, ctx, projectName, repoName, []*models.Reference{r}, map[string]bool{a.Digest: true})
+		if err != nil {
+			return
+		}
+		name := "unknown"
+		if r.Platform != nil {
+			name = platformArch(r.Platform.Architecture, r.Platform.Variant)
+		}
+		g := groups[name]
+		if g == nil {
+			g = &tagGroup{}
+			groups[name] = g
+		}
+		g.count++
+		g.size += size
+	}
+	return
+}
+
+// renderArchTable prints a column per architecture, largest first, and a
+// row per repository.
+func renderArchTable(w io.Writer, res *projectResult) {
+	totals := sumGroups(res, func(v *artifactsSize) map[string]*tagGroup { return v.archGroups })
+	archs := sortedTagGroups(totals)
+	sort.SliceStable(archs, func(i, j int) bool { return totals[archs[i]].size > totals[archs[j]].size })
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("%s by architecture", res.projectName)
+	tw.Style().Title.Align = text.AlignCenter
+	header := table.Row{"Repository"}
+	footer := table.Row{"Total"}
+	for _, arch := range archs {
+		header = append(header, arch)
+		footer = append(footer, humanArtifactSize(totals[arch].size))
+	}
+	tw.AppendHeader(header)
+	for _, v := range res.artifacts {
+		row := table.Row{truncateName(displayRepoName(res.projectName, v.repositoryName), maxColWidth)}
+		for _, arch := range archs {
+			cell := ""
+			if g := v.archGroups[arch]; g != nil {
+				cell = humanArtifactSize(g.size)
+			}
+			row = append(row, cell)
+		}
+		tw.AppendRow(row)
+	}
+	if !noFooter {
+		tw.AppendFooter(footer)
+	}
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var benchRequests int
+var benchRepository string
+
+var benchCmd = &cobra.Command{
+	Use:     "bench",
+	Short:   "Measure latency of harbor list artifacts api",
+	Example: `  hartisize bench --host https://harbor.example.com --project library --requests 100 --concurrency 4`,
+	GroupID: "tool",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		runBench()
+		return
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchRequests, "requests", 20, "Number of list artifacts requests")
+	benchCmd.Flags().StringVar(&benchRepository, "repository", "", "Repository to list (default first repository of the project)")
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench() {
+	prepare()
+	if allProjects || len(hosts) > 1 {
+		log.Fatal("bench supports a single --host and --project")
+	}
+	if benchRequests < 1 {
+		log.Fatal("--requests must be at least 1")
+	}
+	workers := 1
+	if concurrency > 0 {
+		workers = concurrency
+	}
+	ctx := context.TODO()
+	cs, err := connectHost(hosts[0])
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if projectID != 0 {
+		projectName, err = getProjectName(cs, ctx, projectID)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	repoName := benchRepository
+	if repoName == "" {
+		repoName, err = firstRepository(cs, ctx, projectName)
+		if err != nil {
+			exitWithError(err)
+		}
+	} else if !strings.HasPrefix(repoName, projectName+"/") {
+		repoName = projectName + "/" + repoName
+	}
+	log.Infof("bench %d requests listing artifacts of %s", benchRequests, repoName)
+	latencies := make([]time.Duration, benchRequests)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	start := time.Now()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			page := int64(1)
+			for i := range jobs {
+				t := time.Now()
+				_, err := getArtifactList(cs, ctx, projectName, repoName, &defaultCountElements, &page)
+				latencies[i] = time.Since(t)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < benchRequests; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+	if firstErr != nil {
+		exitWithError(classifyError(firstErr))
+	}
+	renderBench(os.Stdout, latencies, elapsed)
+}
+
+func firstRepository(cs *v2client.HarborAPI, ctx context.Context, projectName string) (name string, err error) {
+	page, pageSize := int64(1), int64(1)
+	repos, err := getRepositoryList(cs, ctx, projectName, &pageSize, &page)
+	if err != nil {
+		return "", classifyError(err)
+	}
+	if len(repos.Payload) == 0 {
+		return "", fmt.Errorf("project %s has no repositories", projectName)
+	}
+	return repos.Payload[0].Name, nil
+}
+
+func renderBench(w io.Writer, latencies []time.Duration, elapsed time.Duration) {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum time.Duration
+	for _, l := range sorted {
+		sum += l
+	}
+	p95 := sorted[(95*len(sorted)+99)/100-1]
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Harbor list artifacts latency")
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Requests", "Min", "Max", "Mean", "P95", "Req/s"})
+	tw.AppendRow(table.Row{
+		len(sorted),
+		sorted[0].Round(time.Microsecond),
+		sorted[len(sorted)-1].Round(time.Microsecond),
+		(sum / time.Duration(len(sorted))).Round(time.Microsecond),
+		p95.Round(time.Microsecond),
+		fmt.Sprintf("%.1f", float64(len(sorted))/elapsed.Seconds()),
+	})
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// repoListCache is the repository list of a project as fetched, fields
+// like PullCount, ArtifactCount and UpdateTime are not refreshed until the
+// list expires, so pull counts and --min-pulls, --max-pulls and
+// --never-pulled lag by up to --repo-cache-ttl.
+type repoListCache struct {
+	Host         string               `json:"host"`
+	Project      string               `json:"project"`
+	FetchedAt    time.Time            `json:"fetchedAt"`
+	Repositories []*models.Repository `json:"repositories"`
+}
+
+// scanJournalVersion tags journal entries, entries of another version are
+// scanned again. Version 1 stored the artifact count as countTags.
+const scanJournalVersion = 2
+
+type scanJournalEntry struct {
+	Version       int       `json:"version"`
+	Repository    string    `json:"repository"`
+	Artifacts     int       `json:"artifacts"`
+	Tags          int       `json:"tags"`
+	CountTagged   int       `json:"countTagged"`
+	Size          int64     `json:"size"`
+	AccessorySize int64     `json:"accessorySize"`
+	ImmutableSize int64     `json:"immutableSize"`
+	LastPush      time.Time `json:"lastPush"`
+	ScannedAt     time.Time `json:"scannedAt"`
+}
+
+type scanJournal struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func cachePath(kind string, host string, projectName string) (path string, err error) {
+	var dir string
+	dir, err = os.UserCacheDir()
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256([]byte(host + "\x00" + username + "\x00" + projectName))
+	path = filepath.Join(dir, "hartisize", kind+"-"+hex.EncodeToString(sum[:8])+".json")
+	return
+}
+
+func repoCachePath(host string, projectName string) (path string, err error) {
+	if cacheFile != "" {
+		return cacheFile, nil
+	}
+	return cachePath("repos", host, projectName)
+}
+
+func loadRepoCache(host string, projectName string) (repos []*models.Repository, ok bool) {
+	if noCache || repoCacheTTL <= 0 {
+		return
+	}
+	path, err := repoCachePath(host, projectName)
+	if err != nil {
+		log.Debugf("repo cache disabled: %v", err)
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var c repoListCache
+	if err = json.Unmarshal(data, &c); err != nil {
+		log.Debugf("ignore broken repo cache %s: %v", path, err)
+		return
+	}
+	if c.Host != host || c.Project != projectName || time.Since(c.FetchedAt) > repoCacheTTL {
+		return
+	}
+	log.Debugf("use repo cache %s from %s", path, c.FetchedAt.Format(time.RFC3339))
+	return c.Repositories, true
+}
+
+func saveRepoCache(host string, projectName string, repos []*models.Repository) {
+	if noCache || repoCacheTTL <= 0 {
+		return
+	}
+	path, err := repoCachePath(host, projectName)
+	if err != nil {
+		log.Debugf("repo cache disabled: %v", err)
+		return
+	}
+	data, err := json.Marshal(&repoListCache{
+		Host:         host,
+		Project:      projectName,
+		FetchedAt:    time.Now(),
+		Repositories: repos,
+	})
+	if err != nil {
+		log.Debugf("can't encode repo cache: %v", err)
+		return
+	}
+	if err = writeFileAtomic(path, data, 0o600); err != nil {
+		log.Debugf("can't write repo cache %s: %v", path, err)
+	}
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	var f *os.File
+	f, err = os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Chmod(perm); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// openScanJournal returns repositories already scanned by a previous run
+// when resuming, and a journal to which each finished repository is appended.
+func openScanJournal(host string, projectName string) (done map[string]*artifactsSize, j *scanJournal) {
+	if noCache {
+		return
+	}
+	path, err := cachePath("scan", host, projectName)
+	if err != nil {
+		log.Debugf("scan journal disabled: %v", err)
+		return
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		done = readScanJournal(path)
+		log.Infof("resume scan of project %s, %d repositories already scanned", projectName, len(done))
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		log.Debugf("scan journal disabled: %v", err)
+		return
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		log.Debugf("scan journal disabled: %v", err)
+		return
+	}
+	return done, &scanJournal{f: f, enc: json.NewEncoder(f)}
+}
+
+func readScanJournal(path string) (done map[string]*artifactsSize) {
+	done = make(map[string]*artifactsSize)
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	for {
+		var e scanJournalEntry
+		if err = dec.Decode(&e); err != nil {
+			if err != io.EOF {
+				log.Debugf("stop reading broken scan journal %s: %v", path, err)
+			}
+			return
+		}
+		if e.Version != scanJournalVersion || time.Since(e.ScannedAt) > resumeMaxAge {
+			continue
+		}
+		done[e.Repository] = &artifactsSize{
+			repositoryName: e.Repository,
+			countArtifacts: e.Artifacts,
+			countTags:      e.Tags,
+			countTagged:    e.CountTagged,
+			artifactSize:   e.Size,
+			accessorySize:  e.AccessorySize,
+			immutableSize:  e.ImmutableSize,
+			lastPush:       e.LastPush,
+		}
+	}
+}
+
+func (j *scanJournal) append(repoName string, a *artifactsSize) {
+	if j == nil {
+		return
+	}
+	e := &scanJournalEntry{Version: scanJournalVersion, Repository: repoName, ScannedAt: time.Now()}
+	if a != nil {
+		e.Artifacts = a.countArtifacts
+		e.Tags = a.countTags
+		e.CountTagged = a.countTagged
+		e.Size = a.artifactSize
+		e.AccessorySize = a.accessorySize
+		e.ImmutableSize = a.immutableSize
+		e.LastPush = a.lastPush
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(e); err != nil {
+		log.Debugf("can't write scan journal: %v", err)
+	}
+}
+
+func (j *scanJournal) close() {
+	if j != nil {
+		j.f.Close()
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestScanJournalSkipsOtherVersions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setForTest(t, &noCache, false)
+	setForTest(t, &resume, false)
+	setForTest(t, &resumeMaxAge, time.Hour)
+	_, j := openScanJournal("https://h", "proj")
+	if j == nil {
+		t.Fatal("openScanJournal() returned no journal")
+	}
+	j.append("proj/app", &artifactsSize{repositoryName: "proj/app", countArtifacts: 3, countTags: 5, artifactSize: 30})
+	j.close()
+	path, err := cachePath("scan", "https://h", "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an entry of version 1, countTags was the artifact count
+	old := `{"repository":"proj/web","countTags":4,"tags":4,"size":40,"scannedAt":"` + time.Now().Format(time.RFC3339) + `"}` + "\n"
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(old)
+	f.Close()
+
+	done := readScanJournal(path)
+	if len(done) != 1 || done["proj/app"] == nil {
+		t.Fatalf("readScanJournal() = %d repositories, want only proj/app", len(done))
+	}
+	if a := done["proj/app"]; a.countArtifacts != 3 || a.countTags != 5 || a.artifactSize != 30 {
+		t.Errorf("readScanJournal() proj/app = %d artifacts, %d tags of size %d, want 3, 5 and 30", a.countArtifacts, a.countTags, a.artifactSize)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// applyConfig sets flags not given on the command line or in environment
+// from the config file. Keys are flag names, lists set repeatable flags.
+func applyConfig(flags *pflag.FlagSet) (err error) {
+	path := configFile
+	if path == "" {
+		var dir string
+		if dir, err = os.UserConfigDir(); err != nil {
+			log.Debugf("config file disabled: %v", err)
+			return nil
+		}
+		path = filepath.Join(dir, "hartisize", "config.yaml")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if configFile == "" && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var values map[string]interface{}
+	if err = yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	log.Debugf("read config file %s", path)
+	for name, value := range values {
+		if flags.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("%s: unknown option %q", path, name)
+		}
+		if flagGiven(flags, name) {
+			continue
+		}
+		list, ok := value.([]interface{})
+		if !ok {
+			list = []interface{}{value}
+		}
+		for _, v := range list {
+			if err = flags.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("%s: invalid %s: %w", path, name, err)
+			}
+		}
+	}
+	return
+}
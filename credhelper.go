@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const credentialHelperTimeout = 30 * time.Second
+
+var credentialHelper string
+
+// apiToken is a bearer token from the credential helper, used instead of
+// basic auth when set.
+var apiToken string
+
+// helperCredentials is the helper output, the docker credential helper
+// Username and Secret or username with password or a token.
+type helperCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Secret   string `json:"secret"`
+	Token    string `json:"token"`
+}
+
+// runCredentialHelper runs the helper like a docker credential helper, with
+// get as argument and the server url on stdin, and reads json credentials
+// from its stdout.
+func runCredentialHelper(helper string, urlObj *url.URL) (creds *helperCredentials, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialHelperTimeout)
+	defer cancel()
+	server := (&url.URL{Scheme: urlObj.Scheme, Host: urlObj.Host}).String()
+	log.Debugf("run credential helper %s for %s", helper, server)
+	cmd := exec.CommandContext(ctx, helper, "get")
+	cmd.Stdin = strings.NewReader(server + "\n")
+	cmd.Stderr = os.Stderr
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("credential helper %s: %w", helper, err)
+	}
+	creds = &helperCredentials{}
+	if err = json.Unmarshal(out.Bytes(), creds); err != nil {
+		return nil, fmt.Errorf("credential helper %s returned invalid json: %w", helper, err)
+	}
+	if creds.Password == "" {
+		creds.Password = creds.Secret
+	}
+	// docker helpers mark identity tokens with this username
+	if creds.Username == "<token>" {
+		creds.Username, creds.Token, creds.Password = "", creds.Password, ""
+	}
+	if creds.Token == "" && (creds.Username == "" || creds.Password == "") {
+		return nil, fmt.Errorf("credential helper %s returned no username and password or token", helper)
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"sort"
+	"time"
+)
+
+var danglingArtifacts *artifactCollector
+var danglingOlderThan string
+var danglingBefore time.Time
+
+var danglingCmd = &cobra.Command{
+	Use:     "dangling",
+	Short:   "List artifacts without tags grouped by repository",
+	Example: `  hartisize dangling --host https://harbor.example.com --project library --older-than 30d -o json`,
+	GroupID: "report",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if outputFormat != "table" && outputFormat != "json" {
+			log.Fatalf("dangling supports table or json output, not %q", outputFormat)
+		}
+		if resume {
+			log.Warn("--resume is ignored by dangling, every artifact must be listed")
+			resume = false
+		}
+		if danglingOlderThan != "" {
+			danglingBefore, err = parseSince(danglingOlderThan, time.Now())
+			if err != nil {
+				log.Fatalf("invalid --older-than: %v", err)
+			}
+		}
+		danglingArtifacts = &artifactCollector{}
+		execute()
+		return
+	},
+}
+
+type danglingRepo struct {
+	name      string
+	size      int64
+	artifacts []*artifactEntry
+}
+
+type jsonDanglingRepo struct {
+	Name      string                  `json:"name"`
+	SizeBytes int64                   `json:"size_bytes"`
+	Artifacts []*jsonDanglingArtifact `json:"artifacts"`
+}
+
+type jsonDanglingArtifact struct {
+	Digest    string    `json:"digest"`
+	SizeBytes int64     `json:"size_bytes"`
+	PushTime  time.Time `json:"push_time"`
+}
+
+func init() {
+	danglingCmd.Flags().StringVar(&danglingOlderThan, "older-than", "", "Only artifacts pushed before this, a duration ago (30d, 72h) or a date")
+	rootCmd.AddCommand(danglingCmd)
+}
+
+// danglingRepos groups untagged artifacts by repository, largest first.
+func danglingRepos(c *artifactCollector) (repos []*danglingRepo) {
+	byName := make(map[string]*danglingRepo)
+	for _, a := range c.artifacts {
+		if len(a.tags) > 0 || !danglingBefore.IsZero() && !a.pushTime.Before(danglingBefore) {
+			continue
+		}
+		r := byName[a.repositoryName]
+		if r == nil {
+			r = &danglingRepo{name: a.repositoryName}
+			byName[a.repositoryName] = r
+			repos = append(repos, r)
+		}
+		r.size += a.size
+		r.artifacts = append(r.artifacts, a)
+	}
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].size > repos[j].size })
+	for _, r := range repos {
+		sort.SliceStable(r.artifacts, func(i, j int) bool { return r.artifacts[i].size > r.artifacts[j].size })
+	}
+	return
+}
+
+func renderDangling(w io.Writer, c *artifactCollector) (err error) {
+	repos := danglingRepos(c)
+	var total int64
+	for _, r := range repos {
+		total += r.size
+	}
+	if outputFormat == "json" {
+		out := struct {
+			SchemaVersion int                 `json:"schemaVersion"`
+			Repositories  []*jsonDanglingRepo `json:"repositories"`
+			Total         int64               `json:"total"`
+		}{SchemaVersion: jsonSchemaVersion, Repositories: make([]*jsonDanglingRepo, 0, len(repos)), Total: total}
+		for _, r := range repos {
+			repo := &jsonDanglingRepo{Name: r.name, SizeBytes: r.size}
+			for _, a := range r.artifacts {
+				repo.Artifacts = append(repo.Artifacts, &jsonDanglingArtifact{Digest: a.digest, SizeBytes: a.size, PushTime: a.pushTime})
+			}
+			out.Repositories = append(out.Repositories, repo)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Harbor artifacts without tags")
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Repository", "Digest", "Pushed", "Size"})
+	count := 0
+	for _, r := range repos {
+		for _, a := range r.artifacts {
+			tw.AppendRow(table.Row{r.name, a.digest, a.pushTime.Format(time.DateOnly), humanArtifactSize(a.size)})
+			count++
+		}
+	}
+	tw.AppendFooter(table.Row{"Total", fmt.Sprintf("%d artifacts", count), "", humanArtifactSize(total)})
+	_, err = fmt.Fprintln(w, tw.Render())
+	return
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+var projectDedup bool
+
+// blobSet holds the blobs seen in a project, each counted once in size.
+// partial is set when manifests of some artifacts couldn't be read.
+type blobSet struct {
+	mu      sync.Mutex
+	seen    map[string]bool
+	size    int64
+	partial bool
+}
+
+var dedupMu sync.Mutex
+var dedupSets = make(map[string]*blobSet)
+
+// projectBlobs returns the blob set of a project of the current host.
+func projectBlobs(projectName string) *blobSet {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	key := host + "/" + projectName
+	s := dedupSets[key]
+	if s == nil {
+		s = &blobSet{seen: make(map[string]bool)}
+		dedupSets[key] = s
+	}
+	return s
+}
+
+// resetProjectBlobs forgets blobs of an earlier scan of the project, e.g.
+// with --watch.
+func resetProjectBlobs(projectName string) {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	delete(dedupSets, host+"/"+projectName)
+}
+
+func (s *blobSet) has(digest string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen[digest]
+}
+
+func (s *blobSet) add(digest string, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[digest] {
+		return
+	}
+	s.seen[digest] = true
+	s.size += size
+}
+
+func (s *blobSet) total() (size int64, partial bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size, s.partial
+}
+
+func (s *blobSet) markPartial() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partial = true
+}
+
+// addBlobs adds the manifest, its config and layers, and for an index all
+// referenced manifests to s, manifests already in s are not fetched again.
+func (r *registryClient) addBlobs(ctx context.Context, repoName string, digest string, s *blobSet) (err error) {
+	if s.has(digest) {
+		return
+	}
+	m, raw, err := r.getManifest(ctx, repoName, digest)
+	if err != nil {
+		return
+	}
+	s.add(digest, int64(len(raw)))
+	if m.Config != nil {
+		s.add(m.Config.Digest, m.Config.Size)
+	}
+	for _, l := range m.Layers {
+		s.add(l.Digest, l.Size)
+	}
+	for _, child := range m.Manifests {
+		if err = r.addBlobs(ctx, repoName, child.Digest, s); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestScanProjectDedupKeepsScanningOnManifestError(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	f.addRepo("app", 100, 200)
+	f.addRepo("db", 300)
+	m := &manifest{Config: &manifestDescriptor{Digest: "sha256:config", Size: 10}, Layers: []*manifestDescriptor{{Digest: "sha256:base", Size: 50}}}
+	f.manifests["sha256:app-0"] = m
+	f.manifests["sha256:db-0"] = m
+	setForTest(t, &projectDedup, true)
+	cs := newTestClient(t, f)
+
+	res, err := scanProject(cs, context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("scanProject() error = %v", err)
+	}
+	if res.total != 600 || len(res.artifacts) != 2 {
+		t.Errorf("scanProject() total = %d of %d repositories, want 600 of 2", res.total, len(res.artifacts))
+	}
+	// both manifests count, their shared config and layer once, app-1 has none
+	raw, _ := json.Marshal(m)
+	if want := 2*int64(len(raw)) + 60; res.dedupTotal != want || !res.dedupPartial {
+		t.Errorf("scanProject() deduplicated = %d, partial %v, want %d, partial", res.dedupTotal, res.dedupPartial, want)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+)
+
+const (
+	exitAuth     = 3
+	exitNotFound = 4
+	exitPartial  = 5
+)
+
+var (
+	errUnauthorized         = errors.New("unauthorized, check username and password")
+	errForbidden            = errors.New("forbidden, the account has no access")
+	errProjectNotFound      = errors.New("project not found")
+	errAPIBudget            = errors.New("api call budget exhausted")
+	errAuthRequired         = errors.New("anonymous access denied, the project requires --username and --password")
+	errReferrersUnsupported = errors.New("registry doesn't support the referrers api")
+	errRepoVanished         = errors.New("repository no longer exists")
+	errDuplicateRepos       = errors.New("repositories listed more than once")
+	errRateLimited          = errors.New("rate limited by harbor, lower --concurrency or retry later")
+	errServer               = errors.New("harbor server error")
+)
+
+type repoScanError struct {
+	repositoryName string
+	err            error
+}
+
+func (e *repoScanError) Error() string {
+	return fmt.Sprintf("scan repository %s: %v", e.repositoryName, e.err)
+}
+
+func (e *repoScanError) Unwrap() error {
+	return e.err
+}
+
+type statusError interface {
+	IsCode(code int) bool
+}
+
+func hasStatus(err error, code int) bool {
+	var se statusError
+	return errors.As(err, &se) && se.IsCode(code)
+}
+
+func isServerError(err error) bool {
+	var se interface{ IsServerError() bool }
+	return errors.As(err, &se) && se.IsServerError()
+}
+
+func classifyError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case hasStatus(err, http.StatusUnauthorized) && anonymous:
+		return fmt.Errorf("%w: %v", errAuthRequired, err)
+	case hasStatus(err, http.StatusUnauthorized):
+		return fmt.Errorf("%w: %v", errUnauthorized, err)
+	case hasStatus(err, http.StatusForbidden):
+		return fmt.Errorf("%w: %v", errForbidden, err)
+	case hasStatus(err, http.StatusTooManyRequests):
+		return fmt.Errorf("%w: %v", errRateLimited, err)
+	case isServerError(err):
+		return fmt.Errorf("%w: %v", errServer, err)
+	}
+	return err
+}
+
+// exitCode returns the exit status of a run ending with err.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errUnauthorized), errors.Is(err, errForbidden), errors.Is(err, errAuthRequired):
+		return exitAuth
+	case errors.Is(err, errProjectNotFound):
+		return exitNotFound
+	case errors.Is(err, errAPIBudget):
+		return exitPartial
+	}
+	return 1
+}
+
+func exitWithError(err error) {
+	log.Error(err.Error())
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-openapi/runtime"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		anonymous bool
+		want      error
+		wantCode  int
+	}{
+		{"unauthorized", 401, false, errUnauthorized, exitAuth},
+		{"anonymous unauthorized", 401, true, errAuthRequired, exitAuth},
+		{"forbidden", 403, false, errForbidden, exitAuth},
+		{"rate limited", 429, false, errRateLimited, 1},
+		{"internal server error", 500, false, errServer, 1},
+		{"bad gateway", 502, false, errServer, 1},
+		{"service unavailable", 503, false, errServer, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setForTest(t, &anonymous, tt.anonymous)
+			err := classifyError(runtime.NewAPIError("listArtifacts", nil, tt.status))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("classifyError(%d) = %v, want %v", tt.status, err, tt.want)
+			}
+			if code := exitCode(err); code != tt.wantCode {
+				t.Errorf("exitCode(classifyError(%d)) = %d, want %d", tt.status, code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorKeepsOtherErrors(t *testing.T) {
+	for _, err := range []error{nil, errors.New("connection refused"), runtime.NewAPIError("getArtifact", nil, 404)} {
+		if got := classifyError(err); got != err {
+			t.Errorf("classifyError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: id 7", errProjectNotFound), exitNotFound},
+		{&repoScanError{repositoryName: "proj/app", err: classifyError(runtime.NewAPIError("listArtifacts", nil, 403))}, exitAuth},
+		{fmt.Errorf("scan: %w", errAPIBudget), exitPartial},
+		{errDuplicateRepos, 1},
+		{errors.New("connection refused"), 1},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestScanMissingProjectExitsNotFound(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	cs := newTestClient(t, f)
+	_, err := scanProject(cs, context.Background(), "missing")
+	if !errors.Is(err, errProjectNotFound) || exitCode(err) != exitNotFound {
+		t.Errorf("scanProject() error = %v, exit code %d, want %v and %d", err, exitCode(err), errProjectNotFound, exitNotFound)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func readReposFile(path string, projectName string) (repos []*models.Repository, err error) {
+	var f *os.File
+	f, err = os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := line
+		if !strings.HasPrefix(name, projectName+"/") {
+			name = projectName + "/" + name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		repos = append(repos, &models.Repository{Name: name})
+	}
+	err = scanner.Err()
+	return
+}
+
+func filterRepos(repos []*models.Repository, projectName string) (filtered []*models.Repository) {
+	for _, r := range repos {
+		if !matchesNameContains(r.Name) || isExcluded(r.Name, projectName) {
+			continue
+		}
+		if r.PullCount < minPulls || maxPulls >= 0 && r.PullCount > maxPulls {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return
+}
+
+// excludePattern is a compiled --exclude-repos or ignore file pattern.
+type excludePattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func compileExcludePatterns(patterns []string) (compiled []*excludePattern, err error) {
+	for _, p := range patterns {
+		var re *regexp.Regexp
+		if re, err = compileDoublestar(p); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+		}
+		compiled = append(compiled, &excludePattern{pattern: p, re: re})
+	}
+	return
+}
+
+// isExcluded matches name with and without project prefix against
+// --exclude-repos and ignore file patterns.
+func isExcluded(name string, projectName string) bool {
+	short := strings.TrimPrefix(name, projectName+"/")
+	for _, p := range excludePatterns {
+		if p.re.MatchString(short) || p.re.MatchString(name) {
+			log.Debugf("repository %s excluded by pattern %s", name, p.pattern)
+			return true
+		}
+	}
+	return false
+}
+
+// readIgnoreFile returns glob patterns of path, one per line, blank lines
+// and # comments are skipped.
+func readIgnoreFile(path string) (patterns []string, err error) {
+	var f *os.File
+	f, err = os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	err = scanner.Err()
+	return
+}
+
+func matchesNameContains(name string) bool {
+	if len(repoNameContains) == 0 {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, sub := range repoNameContains {
+		if strings.Contains(name, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByCounts keeps repositories within --min-tags, --max-tags,
+// --min-artifacts and --max-artifacts.
+func filterByCounts(artifacts []*artifactsSize) (filtered []*artifactsSize, dropped int) {
+	for _, a := range artifacts {
+		if a.countTags < minTags || maxTags >= 0 && a.countTags > maxTags ||
+			a.countArtifacts < minArtifacts || maxArtifacts >= 0 && a.countArtifacts > maxArtifacts {
+			dropped++
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return
+}
+
+func hasAnyTag(a *models.Artifact, tags []string) bool {
+	for _, t := range a.Tags {
+		for _, name := range tags {
+			if t.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterMinPercent keeps repositories holding at least --min-percent of
+// total.
+func filterMinPercent(artifacts []*artifactsSize, total int64) (filtered []*artifactsSize, dropped int) {
+	for _, a := range artifacts {
+		if float64(reportedSize(a))*100 < minPercent*float64(total) {
+			dropped++
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return
+}
+
+// filterChangedSince keeps repositories with an artifact pushed after since,
+// repositories without push time are dropped.
+func filterChangedSince(artifacts []*artifactsSize, since time.Time) (filtered []*artifactsSize, dropped int) {
+	for _, a := range artifacts {
+		if !a.lastPush.After(since) {
+			dropped++
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return
+}
+
+// parseSince parses a duration before now, with d for days, or a date.
+func parseSince(s string, now time.Time) (t time.Time, err error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if n, err = strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, derr := time.ParseDuration(s); derr == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err = time.Parse(layout, s); err == nil {
+			return
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a duration nor a date", s)
+}
+
+// duplicateRepos returns names appearing more than once in artifacts.
+func duplicateRepos(artifacts []*artifactsSize) (names []string) {
+	seen := make(map[string]int)
+	for _, v := range artifacts {
+		seen[v.repositoryName]++
+		if seen[v.repositoryName] == 2 {
+			names = append(names, v.repositoryName)
+		}
+	}
+	return
+}
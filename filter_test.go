@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestIsExcluded(t *testing.T) {
+	patterns, err := compileExcludePatterns([]string{"cache/**", "proj/tmp-*", "legacy,old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setForTest(t, &excludePatterns, patterns)
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"proj/cache/npm", true},
+		{"proj/tmp-build", true},
+		{"proj/app", false},
+		{"proj/legacy,old", true},
+		{"proj/legacy", false},
+	}
+	for _, tt := range tests {
+		if got := isExcluded(tt.name, "proj"); got != tt.want {
+			t.Errorf("isExcluded(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompileExcludePatternsInvalid(t *testing.T) {
+	if _, err := compileExcludePatterns([]string{"app", "{team,ops"}); err == nil {
+		t.Error("compileExcludePatterns() with unclosed brace, want error")
+	}
+}
+
+func TestFilterByCounts(t *testing.T) {
+	artifacts := []*artifactsSize{
+		{repositoryName: "proj/untagged", countArtifacts: 5, countTags: 0},
+		{repositoryName: "proj/multi-tag", countArtifacts: 1, countTags: 4},
+		{repositoryName: "proj/app", countArtifacts: 3, countTags: 3},
+	}
+	tests := []struct {
+		minTags, maxTags, minArtifacts, maxArtifacts int
+		want                                         string
+	}{
+		{0, -1, 0, -1, "untagged multi-tag app"},
+		{1, -1, 0, -1, "multi-tag app"},
+		{4, -1, 0, -1, "multi-tag"},
+		{0, 3, 0, -1, "untagged app"},
+		{0, -1, 3, -1, "untagged app"},
+		{0, -1, 0, 3, "multi-tag app"},
+		{1, -1, 2, -1, "app"},
+	}
+	for _, tt := range tests {
+		setForTest(t, &minTags, tt.minTags)
+		setForTest(t, &maxTags, tt.maxTags)
+		setForTest(t, &minArtifacts, tt.minArtifacts)
+		setForTest(t, &maxArtifacts, tt.maxArtifacts)
+		filtered, dropped := filterByCounts(artifacts)
+		if got := repoNames(filtered); got != tt.want || dropped != len(artifacts)-len(filtered) {
+			t.Errorf("filterByCounts(tags %d..%d, artifacts %d..%d) = %s, %d dropped, want %s", tt.minTags, tt.maxTags, tt.minArtifacts, tt.maxArtifacts, got, dropped, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHarbor serves the part of the harbor api a scan uses from in-memory
+// repositories of a single project.
+type fakeHarbor struct {
+	*httptest.Server
+	project string
+	// otherProjects are listed beside project but have no repositories.
+	otherProjects []string
+
+	mu        sync.Mutex
+	repos     []*models.Repository
+	artifacts map[string][]*models.Artifact
+	children  map[string]*models.Artifact
+	manifests map[string]*manifest
+	forbidden map[string]bool
+	// artifactTotal overrides X-Total-Count of the nth artifact list
+	// request of a repository when set, n counts from 1.
+	artifactTotal func(repoName string, n int) int64
+	// requests counts requests by path.
+	requests map[string]int
+}
+
+func newFakeHarbor(t *testing.T, projectName string) *fakeHarbor {
+	t.Helper()
+	f := &fakeHarbor{
+		project:   projectName,
+		artifacts: make(map[string][]*models.Artifact),
+		children:  make(map[string]*models.Artifact),
+		manifests: make(map[string]*manifest),
+		forbidden: make(map[string]bool),
+		requests:  make(map[string]int),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// addRepo adds repository name of the project with an artifact of each
+// size, tagged v1, v2 and so on.
+func (f *fakeHarbor) addRepo(name string, sizes ...int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repos = append(f.repos, &models.Repository{Name: f.project + "/" + name, ArtifactCount: int64(len(sizes))})
+	for i, size := range sizes {
+		f.artifacts[name] = append(f.artifacts[name], &models.Artifact{
+			Digest: fmt.Sprintf("sha256:%s-%d", name, i),
+			Size:   size,
+			Tags:   []*models.Tag{{Name: fmt.Sprintf("v%d", i+1)}},
+		})
+	}
+}
+
+// removeRepo deletes the artifacts of repository name, it stays listed.
+func (f *fakeHarbor) removeRepo(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.artifacts, name)
+}
+
+// forbid answers requests to paths with 403.
+func (f *fakeHarbor) forbid(paths ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range paths {
+		f.forbidden[p] = true
+	}
+}
+
+func (f *fakeHarbor) requestCount(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+func (f *fakeHarbor) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := strings.TrimPrefix(r.URL.Path, "/api/v2.0")
+	f.requests[r.URL.Path]++
+	if f.forbidden[p] {
+		writeHarborError(w, http.StatusForbidden, "FORBIDDEN")
+		return
+	}
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	pageSize, _ := strconv.ParseInt(r.URL.Query().Get("page_size"), 10, 64)
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	switch {
+	case strings.HasPrefix(p, "/v2/") && strings.Contains(p, "/manifests/"):
+		if m := f.manifests[p[strings.LastIndex(p, "/")+1:]]; m != nil {
+			raw, _ := json.Marshal(m)
+			w.Write(raw)
+		} else {
+			writeHarborError(w, http.StatusNotFound, "MANIFEST_UNKNOWN")
+		}
+	case p == "/systeminfo":
+		version := "v2.10.0"
+		writeHarborJSON(w, &models.GeneralInfo{HarborVersion: &version})
+	case p == "/quotas":
+		writeHarborJSON(w, []*models.Quota{{Hard: models.ResourceList{"storage": -1}, Used: models.ResourceList{"storage": 1}}})
+	case p == "/projects":
+		projects := []*models.Project{{Name: f.project, ProjectID: 1}}
+		for i, name := range f.otherProjects {
+			projects = append(projects, &models.Project{Name: name, ProjectID: int32(i + 2)})
+		}
+		writeHarborPage(w, projects, page, pageSize, -1)
+	case len(parts) < 2 || parts[0] != "projects" || parts[1] != f.project && parts[1] != "1":
+		writeHarborError(w, http.StatusNotFound, "NOT_FOUND")
+	case len(parts) == 2:
+		writeHarborJSON(w, &models.Project{Name: f.project, ProjectID: 1})
+	case len(parts) == 3 && parts[2] == "repositories":
+		writeHarborPage(w, f.repos, page, pageSize, -1)
+	case len(parts) >= 5 && parts[2] == "repositories" && parts[4] == "artifacts":
+		repoName, _ := url.QueryUnescape(parts[3])
+		artifacts, ok := f.artifacts[repoName]
+		switch {
+		case !ok:
+			writeHarborError(w, http.StatusNotFound, "NOT_FOUND")
+		case len(parts) == 6:
+			if a := f.children[parts[5]]; a != nil {
+				writeHarborJSON(w, a)
+			} else {
+				writeHarborError(w, http.StatusNotFound, "NOT_FOUND")
+			}
+		default:
+			total := int64(-1)
+			if f.artifactTotal != nil {
+				total = f.artifactTotal(repoName, f.requests[r.URL.Path])
+			}
+			writeHarborPage(w, artifacts, page, pageSize, total)
+		}
+	default:
+		writeHarborError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+// writeHarborPage writes page of items by harbor defaults, with total as
+// X-Total-Count unless it is negative.
+func writeHarborPage[T any](w http.ResponseWriter, items []T, page int64, pageSize int64, total int64) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if total < 0 {
+		total = int64(len(items))
+	}
+	from := min((page-1)*pageSize, int64(len(items)))
+	to := min(from+pageSize, int64(len(items)))
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	writeHarborJSON(w, items[from:to])
+}
+
+func writeHarborJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeHarborError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"errors":[{"code":%q,"message":"%s"}]}`, code, http.StatusText(status))
+}
+
+// setForTest sets *p to v until t ends.
+func setForTest[T any](t *testing.T, p *T, v T) {
+	t.Helper()
+	old := *p
+	*p = v
+	t.Cleanup(func() { *p = old })
+}
+
+// newTestClient points the scan at f with settings for tests and returns
+// a client of it.
+func newTestClient(t *testing.T, f *fakeHarbor) *v2client.HarborAPI {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setForTest(t, &username, "u")
+	setForTest(t, &password, "p")
+	setForTest(t, &progress, false)
+	setForTest(t, &noCache, true)
+	setForTest(t, &host, host)
+	setForTest(t, &registry, registry)
+	cs, err := connectHost(f.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"strings"
+)
+
+// flagSections groups flags in help output, flags of no section are
+// listed last under Other.
+var flagSections = []struct {
+	title string
+	flags []string
+}{
+	{"Connection", []string{"host", "username", "password", "anonymous", "allow-default-creds", "credential-helper", "api-base", "registry-type", "insecure", "ca-cert", "client-cert", "client-key", "header", "connect-timeout", "config", "dry-run"}},
+	{"Scope", []string{"project", "project-id", "all-projects", "exclude-proxy-cache", "repos-from", "repo-name-contains", "exclude-repos", "ignore-file", "tags", "vanished-repos"}},
+	{"Filter", []string{"changed-since", "min-tags", "max-tags", "min-artifacts", "max-artifacts", "min-pulls", "max-pulls", "never-pulled", "min-percent", "max-results"}},
+	{"Output", []string{"output", "ndjson-per-tag", "out-file", "gzip", "prom-file", "openmetrics", "oneline", "strip-project-prefix", "no-footer", "footer-total-bytes", "show-immutable", "show-accessories", "count-accessories", "referrers-api", "show-pulls", "show-pull-ratio", "with-scan-status", "detailed", "show-aliases", "recursive-size", "project-dedup", "group-digits", "precision", "round", "round-to", "with-quota", "plain", "bars", "compact-table", "max-col-width", "overhead-pct", "stats-summary", "pager", "progress", "progress-artifacts"}},
+	{"Sort", []string{"sort", "sort-by", "sort-order", "sortAsc", "sortDsc", "sort-secondary"}},
+	{"Grouping", []string{"tag-group-regex", "group-by-prefix", "group-depth", "tiers", "tier-bounds", "group-by-os", "by-arch", "by-type"}},
+	{"Check", []string{"strict", "post-hook", "fail-over", "fail-repo-over", "verify", "verify-sample", "verify-tolerance", "watch", "delta-only"}},
+	{"Performance", []string{"concurrency", "repo-concurrency-ramp", "ramp-duration", "project-workers", "page-workers", "max-conns", "stream-pagination", "worker-timeout", "max-api-calls"}},
+	{"Cache and resume", []string{"no-cache", "repo-cache-ttl", "cache-file", "resume", "resume-max-age"}},
+	{"Debug", []string{"debug", "dump-responses"}},
+}
+
+// sectionNotes are printed below the title of a flag section.
+var sectionNotes = map[string]string{
+	"Performance": `A scan has at most --concurrency api calls in flight, or without it
+--project-workers times --page-workers while listing repositories, 4 by
+default. --max-conns at or above that never delays a request, set it lower
+to cap connections to a constrained harbor.`,
+}
+
+// usageTemplate is the cobra default with flags of the root command, and
+// global flags of subcommands, split by flagSections.
+const usageTemplate = `Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}{{$cmds := .Commands}}{{if eq (len .Groups) 0}}
+
+Available Commands:{{range $cmds}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{else}}{{range $group := .Groups}}
+
+{{.Title}}{{range $cmds}}{{if (and (eq .GroupID $group.ID) (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
+
+Additional Commands:{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}{{if .HasParent}}
+
+Flags:
+{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}{{else}}{{flagSections .LocalFlags}}{{end}}{{end}}{{if and .HasParent .HasAvailableInheritedFlags}}{{flagSections .InheritedFlags}}{{end}}{{if .HasHelpSubCommands}}
+
+Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`
+
+func init() {
+	cobra.AddTemplateFunc("flagSections", flagSectionUsages)
+	rootCmd.SetUsageTemplate(usageTemplate)
+	rootCmd.AddGroup(
+		&cobra.Group{ID: "report", Title: "Report Commands:"},
+		&cobra.Group{ID: "tool", Title: "Tool Commands:"},
+	)
+}
+
+func flagSectionUsages(flags *pflag.FlagSet) string {
+	var b strings.Builder
+	listed := make(map[string]bool)
+	write := func(title string, fs *pflag.FlagSet) {
+		if usages := strings.TrimRight(fs.FlagUsages(), " \n"); usages != "" {
+			b.WriteString("\n\n" + title + " Flags:\n")
+			if note := sectionNotes[title]; note != "" {
+				b.WriteString("  " + strings.ReplaceAll(note, "\n", "\n  ") + "\n\n")
+			}
+			b.WriteString(usages)
+		}
+	}
+	for _, s := range flagSections {
+		fs := pflag.NewFlagSet(s.title, pflag.ContinueOnError)
+		for _, name := range s.flags {
+			if f := flags.Lookup(name); f != nil {
+				fs.AddFlag(f)
+				listed[name] = true
+			}
+		}
+		write(s.title, fs)
+	}
+	other := pflag.NewFlagSet("other", pflag.ContinueOnError)
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !listed[f.Name] {
+			other.AddFlag(f)
+		}
+	})
+	write("Other", other)
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"sync"
+	"time"
+)
+
+var histogram *ageHistogram
+
+var histogramCmd = &cobra.Command{
+	Use:     "histogram",
+	Short:   "Print count and size of artifacts bucketed by push age",
+	Example: `  hartisize histogram --host https://harbor.example.com --project library`,
+	GroupID: "report",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if resume {
+			log.Warn("--resume is ignored by histogram, every artifact must be listed")
+			resume = false
+		}
+		histogram = newAgeHistogram()
+		execute()
+		return
+	},
+}
+
+type ageBucket struct {
+	name   string
+	maxAge time.Duration
+	count  int
+	size   int64
+}
+
+type ageHistogram struct {
+	mu      sync.Mutex
+	now     time.Time
+	buckets []*ageBucket
+}
+
+func init() {
+	rootCmd.AddCommand(histogramCmd)
+}
+
+func newAgeHistogram() *ageHistogram {
+	day := 24 * time.Hour
+	return &ageHistogram{
+		now: time.Now(),
+		buckets: []*ageBucket{
+			{name: "<1d", maxAge: day},
+			{name: "1-7d", maxAge: 7 * day},
+			{name: "7-30d", maxAge: 30 * day},
+			{name: ">30d"},
+		},
+	}
+}
+
+func (h *ageHistogram) add(a *models.Artifact) {
+	age := h.now.Sub(time.Time(a.PushTime))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, b := range h.buckets {
+		if b.maxAge == 0 || age < b.maxAge {
+			b.count++
+			b.size += a.Size
+			return
+		}
+	}
+}
+
+func renderHistogram(w io.Writer, h *ageHistogram) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Harbor artifacts by push age")
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Age", "Count", "Size"})
+	var count int
+	var size int64
+	for _, b := range h.buckets {
+		tw.AppendRow(table.Row{b.name, b.count, humanArtifactSize(b.size)})
+		count += b.count
+		size += b.size
+	}
+	tw.AppendFooter(table.Row{"Total", count, humanArtifactSize(size)})
+	fmt.Fprintln(w, tw.Render())
+}
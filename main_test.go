@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"testing"
+)
+
+func TestHarborAPIURL(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"https://h", "https://h/api/v2.0"},
+		{"https://h/", "https://h/api/v2.0"},
+		{"https://h/harbor", "https://h/harbor/api/v2.0"},
+		{"https://h/harbor/", "https://h/harbor/api/v2.0"},
+		{"https://h/harbor/api/v2.0", "https://h/harbor/api/v2.0"},
+		{"http://h:8080/api/v2.0/", "http://h:8080/api/v2.0"},
+	}
+	for _, tt := range tests {
+		u, err := harborAPIURL(tt.host)
+		if err != nil {
+			t.Errorf("harborAPIURL(%q) error = %v", tt.host, err)
+			continue
+		}
+		if got := u.String(); got != tt.want {
+			t.Errorf("harborAPIURL(%q) = %s, want %s", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestHarborAPIURLInvalid(t *testing.T) {
+	for _, h := range []string{"h", "ftp://h", "https://", "https://h/%zz"} {
+		if u, err := harborAPIURL(h); err == nil {
+			t.Errorf("harborAPIURL(%q) = %s, want error", h, u)
+		}
+	}
+}
+
+func TestGetReposParallelPages(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	for i := 0; i < 95; i++ {
+		f.addRepo(fmt.Sprintf("app%02d", i), 1)
+	}
+	// harbor may list a repository again on a later page when the order
+	// shifts during the listing
+	f.repos = append(f.repos, f.repos[5])
+	setForTest(t, &defaultCountElements, int64(10))
+	setForTest(t, &pageWorkers, 4)
+	setForTest(t, &streamPagination, false)
+	cs := newTestClient(t, f)
+
+	repos, err := getRepos(cs, context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("getRepos() error = %v", err)
+	}
+	seen := make(map[string]int)
+	for _, r := range repos {
+		seen[r.Name]++
+	}
+	if len(repos) != 95 || len(seen) != 95 {
+		t.Errorf("getRepos() = %d repositories, %d distinct, want 95", len(repos), len(seen))
+	}
+	for name, n := range seen {
+		if n != 1 {
+			t.Errorf("getRepos() listed %s %d times", name, n)
+		}
+	}
+	// one count request and the 10 pages
+	if n := f.requestCount("/api/v2.0/projects/proj/repositories"); n != 11 {
+		t.Errorf("getRepos() made %d repository list requests, want 11", n)
+	}
+}
+
+func TestPageCount(t *testing.T) {
+	setForTest(t, &defaultCountElements, int64(10))
+	tests := []struct {
+		total int64
+		want  int
+	}{
+		{0, 0}, {1, 1}, {9, 1}, {10, 1}, {11, 2}, {15, 2}, {19, 2}, {20, 2}, {21, 3}, {100, 10}, {101, 11},
+	}
+	for _, tt := range tests {
+		if got := pageCount(tt.total); got != tt.want {
+			t.Errorf("pageCount(%d) = %d, want %d", tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestPaginationExactAndPartialPages(t *testing.T) {
+	tests := []struct {
+		total     int
+		streaming bool
+		// requests of the repository list, with the count request unless
+		// streaming
+		wantRequests int
+	}{
+		{9, false, 2},
+		{10, false, 2},
+		{11, false, 3},
+		{20, false, 3},
+		{25, false, 4},
+		{9, true, 1},
+		{10, true, 2},
+		{11, true, 2},
+		{20, true, 3},
+		{25, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d streaming %v", tt.total, tt.streaming), func(t *testing.T) {
+			f := newFakeHarbor(t, "proj")
+			sizes := make([]int64, tt.total)
+			for i := 0; i < tt.total; i++ {
+				f.addRepo(fmt.Sprintf("app%02d", i), 1)
+				sizes[i] = 1
+			}
+			f.addRepo("big", sizes...)
+			setForTest(t, &defaultCountElements, int64(10))
+			setForTest(t, &streamPagination, tt.streaming)
+			cs := newTestClient(t, f)
+			ctx := context.Background()
+
+			f.repos = f.repos[:tt.total]
+			repos, err := getRepos(cs, ctx, "proj")
+			if err != nil {
+				t.Fatalf("getRepos() error = %v", err)
+			}
+			if len(repos) != tt.total {
+				t.Errorf("getRepos() = %d repositories, want %d", len(repos), tt.total)
+			}
+			if n := f.requestCount("/api/v2.0/projects/proj/repositories"); n != tt.wantRequests {
+				t.Errorf("getRepos() made %d requests, want %d", n, tt.wantRequests)
+			}
+			a, err := getRepositoryArtifacts(cs, ctx, "proj", "proj/big", progressFunc(noProgress))
+			if err != nil {
+				t.Fatalf("getRepositoryArtifacts() error = %v", err)
+			}
+			if a.countArtifacts != tt.total || a.artifactSize != int64(tt.total) {
+				t.Errorf("getRepositoryArtifacts() = %d artifacts of size %d, want %d", a.countArtifacts, a.artifactSize, tt.total)
+			}
+			if n := f.requestCount("/api/v2.0/projects/proj/repositories/big/artifacts"); n != tt.wantRequests {
+				t.Errorf("getRepositoryArtifacts() made %d requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRepositoryArtifactsTotalDrift(t *testing.T) {
+	tests := []struct {
+		name string
+		// artifacts listed, and X-Total-Count of the count request
+		artifacts, counted int
+		wantRequests       int
+	}{
+		{"pushed during scan", 25, 10, 4},
+		{"deleted during scan", 15, 30, 3},
+		{"unchanged", 15, 15, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeHarbor(t, "proj")
+			sizes := make([]int64, tt.artifacts)
+			for i := range sizes {
+				sizes[i] = 1
+			}
+			f.addRepo("app", sizes...)
+			f.artifactTotal = func(repoName string, n int) int64 {
+				if n == 1 {
+					return int64(tt.counted)
+				}
+				return int64(tt.artifacts)
+			}
+			setForTest(t, &defaultCountElements, int64(10))
+			setForTest(t, &streamPagination, false)
+			cs := newTestClient(t, f)
+
+			a, err := getRepositoryArtifacts(cs, context.Background(), "proj", "proj/app", progressFunc(noProgress))
+			if err != nil {
+				t.Fatalf("getRepositoryArtifacts() error = %v", err)
+			}
+			if a.countArtifacts != tt.artifacts {
+				t.Errorf("getRepositoryArtifacts() = %d artifacts, want %d", a.countArtifacts, tt.artifacts)
+			}
+			if n := f.requestCount("/api/v2.0/projects/proj/repositories/app/artifacts"); n != tt.wantRequests {
+				t.Errorf("getRepositoryArtifacts() made %d requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRepositoryVanishedDuringScan(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	f.addRepo("app", 10)
+	f.addRepo("team/svc", 20)
+	f.addRepo("web", 30)
+	f.removeRepo("team/svc")
+	cs := newTestClient(t, f)
+	ctx := context.Background()
+
+	setForTest(t, &vanishedRepos, "skip")
+	artifacts, failed, err := getAllArtifacts(cs, ctx, "proj")
+	if err != nil {
+		t.Fatalf("getAllArtifacts() error = %v", err)
+	}
+	if len(artifacts) != 2 {
+		t.Errorf("getAllArtifacts() = %d repositories, want 2", len(artifacts))
+	}
+	if len(failed) != 1 || failed[0].repositoryName != "proj/team/svc" || !errors.Is(failed[0].err, errRepoVanished) {
+		t.Errorf("getAllArtifacts() failed = %v, want proj/team/svc vanished", failed)
+	}
+
+	vanishedRepos = "fail"
+	_, _, err = getAllArtifacts(cs, ctx, "proj")
+	var scanErr *repoScanError
+	if !errors.As(err, &scanErr) || scanErr.repositoryName != "proj/team/svc" {
+		t.Errorf("getAllArtifacts() with --vanished-repos=fail error = %v, want scan error of proj/team/svc", err)
+	}
+}
+
+func TestReferencesSizeStopsOnCycles(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	f.addRepo("app", 1)
+	// c1 and c2 reference each other, c2 also itself
+	f.children["sha256:c1"] = &models.Artifact{Digest: "sha256:c1", Size: 10, References: []*models.Reference{{ChildDigest: "sha256:c2"}}}
+	f.children["sha256:c2"] = &models.Artifact{Digest: "sha256:c2", Size: 20, References: []*models.Reference{{ChildDigest: "sha256:c1"}, {ChildDigest: "sha256:c2"}}}
+	cs := newTestClient(t, f)
+
+	refs := []*models.Reference{{ChildDigest: "sha256:c1"}, {ChildDigest: "sha256:c2"}, {ChildDigest: "sha256:app-0"}, nil}
+	size, err := referencesSize(cs, context.Background(), "proj", "proj/app", refs, map[string]bool{"sha256:app-0": true})
+	if err != nil {
+		t.Fatalf("referencesSize() error = %v", err)
+	}
+	if size != 30 {
+		t.Errorf("referencesSize() = %d, want 30", size)
+	}
+	for _, d := range []string{"sha256:c1", "sha256:c2"} {
+		if n := f.requestCount("/api/v2.0/projects/proj/repositories/app/artifacts/" + d); n != 1 {
+			t.Errorf("referencesSize() fetched %s %d times, want once", d, n)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
+	"sort"
+	"strings"
+)
+
+// artifactOS returns the platform os of a: the config os of an image, or
+// the distinct os of index references joined by +. Harbor does not expose
+// the distribution, so alpine and ubuntu images are both linux.
+func artifactOS(a *models.Artifact) string {
+	if os, ok := a.ExtraAttrs["os"].(string); ok && os != "" {
+		return os
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range a.References {
+		if r.Platform == nil || r.Platform.Os == "" || seen[r.Platform.Os] {
+			continue
+		}
+		seen[r.Platform.Os] = true
+		names = append(names, r.Platform.Os)
+	}
+	if len(names) == 0 {
+		return "unknown"
+	}
+	sort.Strings(names)
+	return strings.Join(names, "+")
+}
+
+func renderOSGroups(w io.Writer, res *projectResult) {
+	groups := sumGroups(res, func(v *artifactsSize) map[string]*tagGroup { return v.osGroups })
+	renderGroupRollup(w, fmt.Sprintf("%s by os", res.projectName), "OS", groups)
+}
+
+// renderTypeGroups prints the --by-type breakdown of res by media type.
+func renderTypeGroups(w io.Writer, res *projectResult) {
+	groups := sumGroups(res, func(v *artifactsSize) map[string]*tagGroup { return v.typeGroups })
+	renderGroupRollup(w, fmt.Sprintf("%s by media type", res.projectName), "Media type", groups)
+}
+
+// addGroup counts a in the group name.
+func addGroup(groups map[string]*tagGroup, name string, a *models.Artifact) {
+	g := groups[name]
+	if g == nil {
+		g = &tagGroup{}
+		groups[name] = g
+	}
+	g.count++
+	g.size += a.Size
+}
+
+// sumGroups sums the groups picked from every repository of res.
+func sumGroups(res *projectResult, pick func(v *artifactsSize) map[string]*tagGroup) map[string]*tagGroup {
+	groups := make(map[string]*tagGroup)
+	for _, v := range res.artifacts {
+		for name, g := range pick(v) {
+			sum := groups[name]
+			if sum == nil {
+				sum = &tagGroup{}
+				groups[name] = sum
+			}
+			sum.count += g.count
+			sum.size += g.size
+		}
+	}
+	return groups
+}
+
+// renderGroupRollup prints groups largest first.
+func renderGroupRollup(w io.Writer, title string, header string, groups map[string]*tagGroup) {
+	names := sortedTagGroups(groups)
+	sort.SliceStable(names, func(i, j int) bool { return groups[names[i]].size > groups[names[j]].size })
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle(title)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{header, "Artifacts", "Size"})
+	for _, name := range names {
+		g := groups[name]
+		tw.AppendRow(table.Row{name, g.count, humanArtifactSize(g.size)})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,710 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonSchemaVersion must be bumped whenever existing JSON fields change or disappear.
+const jsonSchemaVersion = 2
+
+type jsonRepository struct {
+	Name       string          `json:"name"`
+	Artifacts  int             `json:"artifacts"`
+	TagCount   int             `json:"tag_count"`
+	SizeBytes  int64           `json:"size_bytes"`
+	PullCount  int64           `json:"pull_count"`
+	Accessory  *int64          `json:"accessories_size_bytes,omitempty"`
+	Immutable  *int64          `json:"immutable_size_bytes,omitempty"`
+	Mismatches []*jsonMismatch `json:"size_mismatches,omitempty"`
+	Tags       []*jsonTag      `json:"tags,omitempty"`
+	Aliases    []*jsonAlias    `json:"aliases,omitempty"`
+	TagGroups  []*jsonTagGroup `json:"tag_groups,omitempty"`
+	OSGroups   []*jsonTagGroup `json:"os_groups,omitempty"`
+	ArchGroups []*jsonTagGroup `json:"arch_groups,omitempty"`
+	ScanStatus *jsonScanStatus `json:"scan_status,omitempty"`
+}
+
+type jsonScanStatus struct {
+	Unscanned int `json:"unscanned"`
+	Scanning  int `json:"scanning"`
+	Failed    int `json:"failed"`
+}
+
+type jsonTagGroup struct {
+	Group     string `json:"group"`
+	Artifacts int    `json:"artifacts"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+type jsonTag struct {
+	Name      string    `json:"name"`
+	Digest    string    `json:"digest"`
+	SizeBytes int64     `json:"size_bytes"`
+	PushTime  time.Time `json:"push_time"`
+}
+
+type jsonMismatch struct {
+	Digest            string `json:"digest"`
+	ReportedSizeBytes int64  `json:"reported_size_bytes"`
+	ComputedSizeBytes int64  `json:"computed_size_bytes"`
+}
+
+type jsonEnvelope struct {
+	SchemaVersion     int                    `json:"schemaVersion,omitempty"`
+	Host              string                 `json:"host,omitempty"`
+	Project           string                 `json:"project"`
+	ProxyCache        bool                   `json:"proxy_cache,omitempty"`
+	Repositories      []*jsonRepository      `json:"repositories"`
+	Total             *int64                 `json:"total,omitempty"`
+	TotalAdjusted     *int64                 `json:"total_with_overhead,omitempty"`
+	TotalDedup        *int64                 `json:"total_deduplicated,omitempty"`
+	TotalDedupPartial bool                   `json:"total_deduplicated_partial,omitempty"`
+	ByType            map[string]*jsonByType `json:"by_type,omitempty"`
+	Quota             *jsonQuota             `json:"quota,omitempty"`
+	Errors            []*jsonError           `json:"errors,omitempty"`
+}
+
+type jsonByType struct {
+	Count     int   `json:"count"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+type jsonError struct {
+	Host       string `json:"host,omitempty"`
+	Project    string `json:"project,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Message    string `json:"message"`
+}
+
+type jsonProjectsEnvelope struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Projects      []*jsonEnvelope `json:"projects"`
+	Total         *int64          `json:"total,omitempty"`
+	TotalAdjusted *int64          `json:"total_with_overhead,omitempty"`
+	Errors        []*jsonError    `json:"errors,omitempty"`
+}
+
+var sortKeys = map[string]func(a *artifactsSize) int64{
+	"":          func(a *artifactsSize) int64 { return a.artifactSize },
+	"size":      func(a *artifactsSize) int64 { return a.artifactSize },
+	"pulls":     func(a *artifactsSize) int64 { return a.pullCount },
+	"artifacts": func(a *artifactsSize) int64 { return int64(a.countArtifacts) },
+	"tags":      func(a *artifactsSize) int64 { return int64(a.countTags) },
+}
+
+type sortSpec struct {
+	key func(a *artifactsSize) int64
+	asc bool
+}
+
+// parseSortSpecs parses --sort values of form field[:asc|desc].
+func parseSortSpecs(exprs []string) (specs []*sortSpec, err error) {
+	for _, expr := range exprs {
+		field, dir, _ := strings.Cut(expr, ":")
+		key, ok := sortKeys[field]
+		if !ok || field == "" {
+			return nil, fmt.Errorf("unknown sort field %q in --sort %q, expected size, pulls, artifacts or tags", field, expr)
+		}
+		spec := &sortSpec{key: key}
+		switch dir {
+		case "", "desc":
+		case "asc":
+			spec.asc = true
+		default:
+			return nil, fmt.Errorf("unknown sort direction %q in --sort %q, expected asc or desc", dir, expr)
+		}
+		specs = append(specs, spec)
+	}
+	return
+}
+
+// sortResults orders repositories of every result by --sort or --sort-by,
+// so all output formats share one order. Without sort flags scan order is
+// kept.
+func sortResults(results []*projectResult) {
+	specs := sortSpecs
+	if len(specs) == 0 {
+		if !sortAsc && !sortDsc && sortField == "" {
+			return
+		}
+		specs = []*sortSpec{{key: sortKeys[sortField], asc: sortAsc}}
+	}
+	for _, res := range results {
+		sort.SliceStable(res.artifacts, func(i, j int) bool {
+			a, b := res.artifacts[i], res.artifacts[j]
+			for _, s := range specs {
+				if s.key(a) != s.key(b) {
+					return s.key(a) > s.key(b) != s.asc
+				}
+			}
+			return sortSecondary == "name" && a.repositoryName < b.repositoryName
+		})
+	}
+}
+
+// tableStyle returns the colored table style, or with --plain the ascii
+// default style that renders the same everywhere.
+func tableStyle() table.Style {
+	if plainOutput {
+		return table.StyleDefault
+	}
+	return table.StyleColoredDark
+}
+
+func renderTable(w io.Writer, res *projectResult) {
+	if compactTable {
+		renderCompactTable(w, res)
+		return
+	}
+	artifacts, total := res.artifacts, res.total
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	title := fmt.Sprintf("Harbor artifacts size of project - %s", res.projectName)
+	if res.host != "" {
+		title += " on " + res.host
+	}
+	if res.proxyCache {
+		title += " (proxy cache)"
+	}
+	tw.SetTitle(title)
+	header := table.Row{
+		"#",
+		"Repository",
+		"Artifacts",
+		"Tags",
+		"Size",
+	}
+	if showBars {
+		header = append(header, "Share")
+	}
+	if showAccessories {
+		header = append(header, "Accessories")
+	}
+	if showImmutable {
+		header = append(header, "Immutable")
+	}
+	if withScanStatus {
+		header = append(header, "Unscanned", "Scanning", "ScanFailed")
+	}
+	if groupDigits {
+		header = append(header, "Bytes")
+	}
+	if showPullRatio {
+		header = append(header, "PullsPerTag")
+	}
+	tw.AppendHeader(append(header, "PullCount", "SizeInt"))
+	tw.Style().Title.Align = text.AlignCenter
+	var largest int64
+	for _, v := range artifacts {
+		largest = max(largest, v.artifactSize)
+	}
+	for k, v := range artifacts {
+		row := table.Row{
+			k,
+			truncateName(displayRepoName(res.projectName, v.repositoryName), maxColWidth),
+			v.countArtifacts,
+			v.countTags,
+			humanArtifactSize(v.artifactSize),
+		}
+		if showBars {
+			row = append(row, sizeBar(v.artifactSize, largest))
+		}
+		if showAccessories {
+			row = append(row, humanArtifactSize(v.accessorySize))
+		}
+		if showImmutable {
+			row = append(row, humanArtifactSize(v.immutableSize))
+		}
+		if withScanStatus {
+			row = append(row, v.scanStatus.unscanned, v.scanStatus.scanning, v.scanStatus.failed)
+		}
+		if groupDigits {
+			row = append(row, formatGroupedInt(v.artifactSize))
+		}
+		if showPullRatio {
+			row = append(row, pullRatio(v))
+		}
+		tw.AppendRow(append(row, v.pullCount, v.artifactSize))
+	}
+	var artifactCount, tagCount int
+	for _, v := range artifacts {
+		artifactCount += v.countArtifacts
+		tagCount += v.countTags
+	}
+	footer := table.Row{
+		"Repositories",
+		fmt.Sprintf("%v", len(artifacts)),
+		artifactCount,
+		tagCount,
+		humanArtifactSize(total),
+	}
+	if showBars {
+		footer = append(footer, "")
+	}
+	if showAccessories {
+		var accessories int64
+		for _, v := range artifacts {
+			accessories += v.accessorySize
+		}
+		footer = append(footer, humanArtifactSize(accessories))
+	}
+	if showImmutable {
+		var immutable int64
+		for _, v := range artifacts {
+			immutable += v.immutableSize
+		}
+		footer = append(footer, humanArtifactSize(immutable))
+	}
+	if withScanStatus {
+		var counts scanStatusCounts
+		for _, v := range artifacts {
+			counts.unscanned += v.scanStatus.unscanned
+			counts.scanning += v.scanStatus.scanning
+			counts.failed += v.scanStatus.failed
+		}
+		footer = append(footer, counts.unscanned, counts.scanning, counts.failed)
+	}
+	if groupDigits {
+		footer = append(footer, formatGroupedInt(total))
+	}
+	if showPullRatio && footerTotalBytes {
+		footer = append(footer, "")
+	}
+	if footerTotalBytes {
+		footer = append(footer, "", total)
+	}
+	if !noFooter {
+		tw.AppendFooter(footer)
+		if overheadPct > 0 {
+			tw.AppendFooter(table.Row{"", "", "", fmt.Sprintf("+%g%% overhead", overheadPct), humanArtifactSize(withOverhead(total))})
+		}
+		if projectDedup {
+			label := "Deduplicated"
+			if res.dedupPartial {
+				label += " (partial)"
+			}
+			tw.AppendFooter(table.Row{"", "", "", label, humanArtifactSize(res.dedupTotal)})
+		}
+	}
+	if res.quota != nil {
+		tw.SetCaption("%s", res.quota)
+	}
+
+	// one SetColumnConfigs call, a later call replaces all earlier configs
+	tw.SetColumnConfigs([]table.ColumnConfig{{
+		Name:        "Dark",
+		Align:       text.AlignCenter,
+		AlignHeader: text.AlignCenter,
+	}, {
+		Name:   "SizeInt",
+		Hidden: !footerTotalBytes,
+	}, {
+		Name:   "PullCount",
+		Hidden: !showPulls,
+	}, {
+		Name:  "PullsPerTag",
+		Align: text.AlignRight,
+	}, {
+		Name:        "Bytes",
+		Align:       text.AlignRight,
+		AlignFooter: text.AlignRight,
+	}})
+	fmt.Fprintln(w, tw.Render())
+}
+
+const barWidth = 20
+
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// sizeBar returns a bar of size relative to the largest repository, in
+// eighths of a character so small repositories still show.
+func sizeBar(size, largest int64) string {
+	if largest <= 0 || size <= 0 {
+		return ""
+	}
+	eighths := max(int(size*barWidth*8/largest), 1)
+	return strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+}
+
+// renderCompactTable prints only repository and size columns, for narrow
+// terminals.
+func renderCompactTable(w io.Writer, res *projectResult) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle(res.projectName)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Repository", "Size"})
+	for _, v := range res.artifacts {
+		tw.AppendRow(table.Row{truncateName(displayRepoName(res.projectName, v.repositoryName), maxColWidth), humanArtifactSize(v.artifactSize)})
+	}
+	if !noFooter {
+		tw.AppendFooter(table.Row{"Total", humanArtifactSize(res.total)})
+	}
+	if res.quota != nil {
+		tw.SetCaption("%s", res.quota)
+	}
+	fmt.Fprintln(w, tw.Render())
+}
+
+// pullRatio returns pulls of the repository per artifact, low values point
+// to storage that is pushed but rarely read.
+func pullRatio(v *artifactsSize) string {
+	if v.countArtifacts == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(float64(v.pullCount)/float64(v.countArtifacts), 'f', 1, 64)
+}
+
+func renderHostsTotal(w io.Writer, results []*projectResult) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Harbor artifacts size across hosts")
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Host", "Project", "Size"})
+	var total int64
+	for _, res := range results {
+		tw.AppendRow(table.Row{res.host, res.projectName, humanArtifactSize(res.total)})
+		total += res.total
+	}
+	tw.AppendFooter(table.Row{"", "TotalSize", humanArtifactSize(total)})
+	fmt.Fprintln(w, tw.Render())
+}
+
+func renderStatsSummary(w io.Writer, res *projectResult) {
+	if len(res.artifacts) == 0 {
+		return
+	}
+	sizes := make([]int64, 0, len(res.artifacts))
+	for _, v := range res.artifacts {
+		sizes = append(sizes, v.artifactSize)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	// nearest-rank percentile
+	percentile := func(p int) int64 {
+		rank := (p*len(sizes) + 99) / 100
+		return sizes[rank-1]
+	}
+	var sum int64
+	for _, s := range sizes {
+		sum += s
+	}
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Repository size summary of project - %s", res.projectName)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Mean", "P50", "P90", "P99"})
+	tw.AppendRow(table.Row{
+		humanArtifactSize(sum / int64(len(sizes))),
+		humanArtifactSize(percentile(50)),
+		humanArtifactSize(percentile(90)),
+		humanArtifactSize(percentile(99)),
+	})
+	fmt.Fprintln(w, tw.Render())
+}
+
+func formatGroupedInt(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+func displayRepoName(projectName string, name string) string {
+	if stripProjectPrefix {
+		return strings.TrimPrefix(name, projectName+"/")
+	}
+	return name
+}
+
+// withOverhead adds --overhead-pct to size, an estimate of registry
+// metadata on disk.
+func withOverhead(size int64) int64 {
+	return size + int64(float64(size)*overheadPct/100)
+}
+
+// truncateName shortens name to width runes ending with an ellipsis,
+// width 0 keeps name as is.
+func truncateName(name string, width int) string {
+	runes := []rune(name)
+	if width <= 0 || len(runes) <= width {
+		return name
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func newJSONEnvelope(res *projectResult) (env *jsonEnvelope) {
+	env = &jsonEnvelope{
+		SchemaVersion: jsonSchemaVersion,
+		Host:          res.host,
+		Project:       res.projectName,
+		ProxyCache:    res.proxyCache,
+		Repositories:  make([]*jsonRepository, 0, len(res.artifacts)),
+	}
+	if !noFooter {
+		total := res.total
+		env.Total = &total
+		if overheadPct > 0 {
+			adjusted := withOverhead(total)
+			env.TotalAdjusted = &adjusted
+		}
+		if projectDedup {
+			dedup := res.dedupTotal
+			env.TotalDedup = &dedup
+			env.TotalDedupPartial = res.dedupPartial
+		}
+	}
+	if res.quota != nil {
+		env.Quota = &jsonQuota{UsedBytes: res.quota.used, HardBytes: res.quota.hard}
+		if p, ok := res.quota.percent(); ok {
+			env.Quota.Percent = &p
+		}
+	}
+	if byType {
+		env.ByType = make(map[string]*jsonByType)
+		for name, g := range sumGroups(res, func(v *artifactsSize) map[string]*tagGroup { return v.typeGroups }) {
+			env.ByType[name] = &jsonByType{Count: g.count, SizeBytes: g.size}
+		}
+	}
+	for _, v := range res.artifacts {
+		repo := &jsonRepository{
+			Name:      v.repositoryName,
+			Artifacts: v.countArtifacts,
+			TagCount:  v.countTags,
+			SizeBytes: v.artifactSize,
+			PullCount: v.pullCount,
+		}
+		if showAccessories || countAccessories {
+			accessorySize := v.accessorySize
+			repo.Accessory = &accessorySize
+		}
+		if v.scanStatus != nil {
+			repo.ScanStatus = &jsonScanStatus{Unscanned: v.scanStatus.unscanned, Scanning: v.scanStatus.scanning, Failed: v.scanStatus.failed}
+		}
+		if showImmutable {
+			immutableSize := v.immutableSize
+			repo.Immutable = &immutableSize
+		}
+		for _, m := range v.mismatches {
+			repo.Mismatches = append(repo.Mismatches, &jsonMismatch{
+				Digest:            m.digest,
+				ReportedSizeBytes: m.reported,
+				ComputedSizeBytes: m.computed,
+			})
+		}
+		for _, t := range v.details {
+			repo.Tags = append(repo.Tags, &jsonTag{
+				Name:      t.name,
+				Digest:    t.digest,
+				SizeBytes: t.size,
+				PushTime:  t.pushTime,
+			})
+		}
+		if showAliases {
+			aliases, _, _ := digestAliases(v.details)
+			for _, a := range aliases {
+				repo.Aliases = append(repo.Aliases, &jsonAlias{Digest: a.digest, SizeBytes: a.size, Tags: a.tags})
+			}
+		}
+		for _, name := range sortedTagGroups(v.tagGroups) {
+			g := v.tagGroups[name]
+			repo.TagGroups = append(repo.TagGroups, &jsonTagGroup{Group: name, Artifacts: g.count, SizeBytes: g.size})
+		}
+		for _, name := range sortedTagGroups(v.osGroups) {
+			g := v.osGroups[name]
+			repo.OSGroups = append(repo.OSGroups, &jsonTagGroup{Group: name, Artifacts: g.count, SizeBytes: g.size})
+		}
+		for _, name := range sortedTagGroups(v.archGroups) {
+			g := v.archGroups[name]
+			repo.ArchGroups = append(repo.ArchGroups, &jsonTagGroup{Group: name, Artifacts: g.count, SizeBytes: g.size})
+		}
+		env.Repositories = append(env.Repositories, repo)
+	}
+	for _, f := range res.failed {
+		env.Errors = append(env.Errors, &jsonError{Repository: f.repositoryName, Message: f.err.Error()})
+	}
+	return
+}
+
+// delimitedRows returns the header and rows of csv and tsv output, host
+// column is added only when several hosts are scanned.
+func delimitedRows(results []*projectResult) (rows [][]string) {
+	header := []string{"project", "repository", "artifacts", "size_bytes", "size", "tags"}
+	if len(hosts) > 1 {
+		header = append([]string{"host"}, header...)
+	}
+	rows = append(rows, header)
+	for _, res := range results {
+		for _, v := range res.artifacts {
+			var row []string
+			if len(hosts) > 1 {
+				row = append(row, res.host)
+			}
+			rows = append(rows, append(row,
+				res.projectName,
+				v.repositoryName,
+				strconv.Itoa(v.countArtifacts),
+				strconv.FormatInt(v.artifactSize, 10),
+				humanArtifactSize(v.artifactSize),
+				strconv.Itoa(v.countTags),
+			))
+		}
+	}
+	return
+}
+
+func renderCSV(w io.Writer, results []*projectResult) (err error) {
+	cw := csv.NewWriter(w)
+	if err = cw.WriteAll(delimitedRows(results)); err != nil {
+		return
+	}
+	return cw.Error()
+}
+
+func renderTSV(w io.Writer, results []*projectResult) (err error) {
+	for _, row := range delimitedRows(results) {
+		if _, err = fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func renderOneline(w io.Writer, results []*projectResult) (err error) {
+	for _, res := range results {
+		artifacts, tags := 0, 0
+		for _, v := range res.artifacts {
+			artifacts += v.countArtifacts
+			tags += v.countTags
+		}
+		if res.host != "" {
+			if _, err = fmt.Fprintf(w, "host=%s ", res.host); err != nil {
+				return
+			}
+		}
+		_, err = fmt.Fprintf(w, "project=%s repos=%d artifacts=%d tags=%d size=%s", res.projectName, len(res.artifacts), artifacts, tags, humanArtifactSize(res.total))
+		if err != nil {
+			return
+		}
+		if overheadPct > 0 {
+			if _, err = fmt.Fprintf(w, " size_with_overhead=%s", humanArtifactSize(withOverhead(res.total))); err != nil {
+				return
+			}
+		}
+		if projectDedup {
+			if _, err = fmt.Fprintf(w, " size_deduplicated=%s", humanArtifactSize(res.dedupTotal)); err != nil {
+				return
+			}
+			if res.dedupPartial {
+				if _, err = fmt.Fprint(w, " size_deduplicated_partial=true"); err != nil {
+					return
+				}
+			}
+		}
+		if res.proxyCache {
+			if _, err = fmt.Fprint(w, " proxy_cache=true"); err != nil {
+				return
+			}
+		}
+		if res.quota != nil {
+			hard := "unlimited"
+			if res.quota.hard > 0 {
+				hard = humanArtifactSize(res.quota.hard)
+			}
+			if _, err = fmt.Fprintf(w, " quota_used=%s quota_hard=%s", humanArtifactSize(res.quota.used), hard); err != nil {
+				return
+			}
+		}
+		_, err = fmt.Fprintln(w)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func renderJSON(w io.Writer, results []*projectResult) (err error) {
+	var out interface{}
+	if allProjects || len(hosts) > 1 {
+		env := &jsonProjectsEnvelope{
+			SchemaVersion: jsonSchemaVersion,
+			Projects:      make([]*jsonEnvelope, 0, len(results)),
+		}
+		var total int64
+		for _, res := range results {
+			p := newJSONEnvelope(res)
+			p.SchemaVersion = 0
+			env.Errors = append(env.Errors, p.Errors...)
+			p.Errors = nil
+			env.Projects = append(env.Projects, p)
+			total += res.total
+		}
+		for _, f := range skippedProjects {
+			e := &jsonError{Project: f.projectName, Message: f.err.Error()}
+			if len(hosts) > 1 {
+				e.Host = f.host
+			}
+			env.Errors = append(env.Errors, e)
+		}
+		if !noFooter {
+			env.Total = &total
+			if overheadPct > 0 {
+				adjusted := withOverhead(total)
+				env.TotalAdjusted = &adjusted
+			}
+		}
+		out = env
+	} else {
+		out = newJSONEnvelope(results[0])
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(out)
+	return
+}
+
+// openOutput returns the writer of the report, --out-file or stdout, gzip
+// compressed with --gzip or a .gz file. closeOutput flushes and closes it
+// and must be called on error too.
+func openOutput() (w io.Writer, closeOutput func() error, err error) {
+	w, closeOutput = os.Stdout, func() error { return nil }
+	if outFile != "" {
+		var f *os.File
+		if f, err = os.Create(outFile); err != nil {
+			return nil, nil, err
+		}
+		w, closeOutput = f, f.Close
+	}
+	if !gzipOutput && !strings.HasSuffix(outFile, ".gz") {
+		return
+	}
+	zw := gzip.NewWriter(w)
+	closeFile := closeOutput
+	closeOutput = func() error {
+		err := zw.Close()
+		if cerr := closeFile(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return zw, closeOutput, nil
+}
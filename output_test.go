@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// sizedRepos returns repositories whose human sizes sort differently as
+// strings than as numbers, e.g. "9.0MiB" after "10.0GiB".
+func sizedRepos() []*artifactsSize {
+	return []*artifactsSize{
+		{repositoryName: "proj/nine-mib", artifactSize: 9 << 20, countArtifacts: 1},
+		{repositoryName: "proj/ten-gib", artifactSize: 10 << 30, countArtifacts: 1},
+		{repositoryName: "proj/half-mib", artifactSize: 512 << 10, countArtifacts: 1},
+		{repositoryName: "proj/hundred-mib", artifactSize: 100 << 20, countArtifacts: 1},
+	}
+}
+
+func repoNames(artifacts []*artifactsSize) string {
+	var names []string
+	for _, v := range artifacts {
+		names = append(names, strings.TrimPrefix(v.repositoryName, "proj/"))
+	}
+	return strings.Join(names, " ")
+}
+
+func TestSortResultsBySizeIsNumeric(t *testing.T) {
+	tests := []struct {
+		asc, dsc bool
+		want     string
+	}{
+		{true, false, "half-mib nine-mib hundred-mib ten-gib"},
+		{false, true, "ten-gib hundred-mib nine-mib half-mib"},
+	}
+	for _, tt := range tests {
+		setForTest(t, &sortAsc, tt.asc)
+		setForTest(t, &sortDsc, tt.dsc)
+		setForTest(t, &sortField, "size")
+		setForTest(t, &sortSpecs, nil)
+		res := &projectResult{projectName: "proj", artifacts: sizedRepos()}
+		sortResults([]*projectResult{res})
+		if got := repoNames(res.artifacts); got != tt.want {
+			t.Errorf("sortResults(asc %v) = %s, want %s", tt.asc, got, tt.want)
+		}
+	}
+}
+
+func TestSortResultsSecondaryByName(t *testing.T) {
+	setForTest(t, &sortSpecs, []*sortSpec{{key: sortKeys["artifacts"], asc: false}})
+	setForTest(t, &sortSecondary, "name")
+	res := &projectResult{projectName: "proj", artifacts: sizedRepos()}
+	sortResults([]*projectResult{res})
+	if got, want := repoNames(res.artifacts), "half-mib hundred-mib nine-mib ten-gib"; got != want {
+		t.Errorf("sortResults() = %s, want %s", got, want)
+	}
+}
+
+func TestRenderTableKeepsNumericOrder(t *testing.T) {
+	setForTest(t, &sortDsc, true)
+	setForTest(t, &sortField, "size")
+	setForTest(t, &sortSpecs, nil)
+	setForTest(t, &plainOutput, true)
+	res := &projectResult{projectName: "proj", artifacts: sizedRepos()}
+	sortResults([]*projectResult{res})
+	var buf bytes.Buffer
+	renderTable(&buf, res)
+	out := buf.String()
+	last := -1
+	for _, name := range strings.Fields("ten-gib hundred-mib nine-mib half-mib") {
+		i := strings.Index(out, name)
+		if i < 0 || i < last {
+			t.Fatalf("renderTable() rows out of size order:\n%s", out)
+		}
+		last = i
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+	"os"
+	"os/exec"
+)
+
+// writePaged writes out to stdout, through $PAGER when --pager is set and
+// out does not fit the terminal, never with --plain.
+func writePaged(out []byte) {
+	fd := int(os.Stdout.Fd())
+	if usePager && !plainOutput && term.IsTerminal(fd) {
+		_, height, err := term.GetSize(fd)
+		if err == nil && bytes.Count(out, []byte("\n")) >= height {
+			pager := os.Getenv("PAGER")
+			if pager == "" {
+				pager = "less -R"
+			}
+			cmd := exec.Command("sh", "-c", pager)
+			cmd.Stdin = bytes.NewReader(out)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if os.Getenv("LESS") == "" {
+				cmd.Env = append(os.Environ(), "LESS=FRX")
+			}
+			if err = cmd.Run(); err == nil {
+				return
+			}
+			log.Debugf("pager %q failed: %v", pager, err)
+		}
+	}
+	_, _ = os.Stdout.Write(out)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"os/exec"
+)
+
+var postHook string
+
+// runPostHook runs the hook with the json report on stdin and returns its
+// exit code, hook output goes to our stdout and stderr.
+func runPostHook(hook string, results []*projectResult) (code int, err error) {
+	var buf bytes.Buffer
+	if err = renderJSON(&buf, results); err != nil {
+		return
+	}
+	log.Debugf("run post hook %s", hook)
+	cmd := exec.Command(hook)
+	cmd.Stdin = &buf
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("post hook %s: %w", hook, err)
+	}
+	return
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// prefixGroup returns the first depth path segments of repoName without
+// the project, or the whole name when it has fewer segments.
+func prefixGroup(projectName string, repoName string, depth int) string {
+	segments := strings.Split(strings.TrimPrefix(repoName, projectName+"/"), "/")
+	if depth < len(segments) {
+		segments = segments[:depth]
+	}
+	return strings.Join(segments, "/")
+}
+
+func renderPrefixGroups(w io.Writer, res *projectResult) {
+	groups := make(map[string]*tagGroup)
+	for _, v := range res.artifacts {
+		name := prefixGroup(res.projectName, v.repositoryName, groupDepth)
+		g := groups[name]
+		if g == nil {
+			g = &tagGroup{}
+			groups[name] = g
+		}
+		g.count += v.countArtifacts
+		g.size += v.artifactSize
+	}
+	renderGroupRollup(w, fmt.Sprintf("%s by prefix", res.projectName), "Prefix", groups)
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestPrefixGroup(t *testing.T) {
+	tests := []struct {
+		repoName string
+		depth    int
+		want     string
+	}{
+		{"proj/app", 1, "app"},
+		{"proj/app", 2, "app"},
+		{"proj/team/app", 1, "team"},
+		{"proj/team/app", 2, "team/app"},
+		{"proj/team/backend/app", 1, "team"},
+		{"proj/team/backend/app", 2, "team/backend"},
+		{"proj/team/backend/app", 3, "team/backend/app"},
+		{"proj/team/backend/app", 5, "team/backend/app"},
+	}
+	for _, tt := range tests {
+		if got := prefixGroup("proj", tt.repoName, tt.depth); got != tt.want {
+			t.Errorf("prefixGroup(%q, %d) = %q, want %q", tt.repoName, tt.depth, got, tt.want)
+		}
+	}
+}
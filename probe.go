@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/client/systeminfo"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+var registryType string
+
+// probeRegistry checks with --registry-type auto that host serves the
+// harbor api. Only harbor mode is implemented, a host without systeminfo is
+// an error rather than a fallback to a generic registry.
+func probeRegistry(cs *v2client.HarborAPI, ctx context.Context) (err error) {
+	log.Debugf("try probe registry type of %s", host)
+	res, err := cs.Systeminfo.GetSystemInfo(ctx, systeminfo.NewGetSystemInfoParams())
+	switch {
+	case hasStatus(err, http.StatusNotFound):
+		return fmt.Errorf("%s has no harbor api at %s, generic registries are not supported", host, apiBasePath)
+	case hasStatus(err, http.StatusForbidden):
+		log.Debugf("no access to systeminfo of %s, assuming harbor: %v", host, err)
+		return nil
+	case err != nil:
+		log.Warnf("can't probe registry type of %s, assuming harbor: %v", host, err)
+		return nil
+	}
+	version := "unknown version"
+	if res.Payload.HarborVersion != nil {
+		version = *res.Payload.HarborVersion
+	}
+	log.Infof("detected harbor %s at %s, using harbor mode", version, host)
+	return
+}
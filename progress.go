@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"github.com/schollz/progressbar/v3"
+	"os"
+)
+
+type progressKind int
+
+const (
+	repoStarted progressKind = iota
+	artifactsListed
+	repoScanned
+)
+
+// progressEvent reports scan progress of one repository, index is 1-based
+// in total repositories of the project, artifacts is set by artifactsListed.
+type progressEvent struct {
+	kind      progressKind
+	repoName  string
+	index     int
+	total     int
+	artifacts int
+}
+
+// progressFunc receives progress events of a scan, it is called from
+// concurrent repository scans.
+type progressFunc func(e progressEvent)
+
+func noProgress(progressEvent) {}
+
+// newProgressBar returns a progressFunc driving a terminal progress bar of
+// max repositories, or artifacts with --progress-artifacts.
+func newProgressBar(max int64) progressFunc {
+	bar := progressbar.NewOptions64(max,
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionSetWriter(os.Stderr),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Fprintf(os.Stderr, "\n")
+		}),
+		progressbar.OptionFullWidth())
+	return func(e progressEvent) {
+		switch e.kind {
+		case repoStarted:
+			bar.Describe(fmt.Sprintf("[green]🚀	%s [yellow]", e.repoName))
+			if !progressArtifacts {
+				_ = bar.Add(1)
+			}
+		case artifactsListed:
+			if progressArtifacts {
+				_ = bar.Add(e.artifacts)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/client/project"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// skippedProjects are projects of --all-projects whose scan failed, the
+// report shows the others.
+var skippedProjects []*projectFailure
+
+type projectFailure struct {
+	host        string
+	projectName string
+	err         error
+}
+
+// getProjects lists the projects visible to the user, none when it may not
+// list projects at all.
+func getProjects(cs *v2client.HarborAPI, ctx context.Context) (projects []*models.Project, err error) {
+	log.Debugf("try get projects")
+	var projectCount int
+	projectCount, err = getCountElements(cs, ctx, "projectList", "", "")
+	if hasStatus(err, http.StatusForbidden) {
+		log.Debugf("no access to the project list, skip project enumeration: %v", err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	for i := 1; i <= projectCount; i++ {
+		var res *project.ListProjectsOK
+		page := int64(i)
+		res, err = cs.Project.ListProjects(ctx, project.NewListProjectsParams().WithPage(&page).WithPageSize(&defaultCountElements))
+		if hasStatus(err, http.StatusForbidden) {
+			log.Debugf("no access to page %d of the project list, skip the rest: %v", i, err)
+			return projects, nil
+		}
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		projects = append(projects, res.Payload...)
+	}
+	return
+}
+
+// isProxyCache reports whether projectName mirrors an upstream registry. A
+// user without access to the project metadata gets false, the scan itself
+// only needs the repository api.
+func isProxyCache(cs *v2client.HarborAPI, ctx context.Context, projectName string) (proxy bool, err error) {
+	log.Debugf("try get registry of project %s", projectName)
+	res, err := cs.Project.GetProject(ctx, project.NewGetProjectParams().WithProjectNameOrID(projectName))
+	if err != nil {
+		switch {
+		case hasStatus(err, http.StatusNotFound):
+			return false, fmt.Errorf("%w: %s", errProjectNotFound, projectName)
+		case hasStatus(err, http.StatusForbidden):
+			log.Debugf("no access to project %s metadata, assuming it is no proxy cache: %v", projectName, err)
+			return false, nil
+		}
+		return false, classifyError(err)
+	}
+	return res.Payload.RegistryID != 0, nil
+}
+
+// needProxyCache reports whether a single project scan has to know if the
+// project is a proxy cache, to exclude it or to label it in the report.
+func needProxyCache() bool {
+	if excludeProxyCache || postHook != "" {
+		return true
+	}
+	if tagStream != nil || histogram != nil || topArtifacts != nil || danglingArtifacts != nil {
+		return false
+	}
+	return oneline || outputFormat == "json" || outputFormat == "table"
+}
+
+func scanAllProjects(cs *v2client.HarborAPI, ctx context.Context) (results []*projectResult, err error) {
+	var projects []*models.Project
+	projects, err = getProjects(cs, ctx)
+	if err != nil {
+		return
+	}
+	if len(projects) == 0 {
+		log.Warnf("no projects visible on %s", host)
+	}
+	names := make([]string, 0, len(projects))
+	proxy := make(map[string]bool)
+	for _, p := range projects {
+		if p.RegistryID != 0 {
+			if excludeProxyCache {
+				log.Infof("project %s is a proxy cache, excluded by --exclude-proxy-cache", p.Name)
+				continue
+			}
+			proxy[p.Name] = true
+		}
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	workers := projectWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > 1 && progress {
+		log.Debugf("progress bar disabled for %d concurrent projects", workers)
+		progress = false
+	}
+	results = make([]*projectResult, len(names))
+	errs := make([]error, len(names))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			log.Debugf("try scan project %s", name)
+			results[i], errs[i] = scanProject(cs, ctx, name)
+			if errs[i] == nil {
+				results[i].proxyCache = proxy[name]
+			}
+		}(i, name)
+	}
+	wg.Wait()
+	scanned := results[:0]
+	for i, e := range errs {
+		switch {
+		case errors.Is(e, errUnauthorized), errors.Is(e, errAuthRequired):
+			return nil, e
+		case errors.Is(e, errAPIBudget):
+			log.Warnf("project %s skipped, api call budget exhausted", names[i])
+			skippedProjects = append(skippedProjects, &projectFailure{host: host, projectName: names[i], err: e})
+		case e != nil:
+			log.Warnf("project %s skipped: %v", names[i], e)
+			skippedProjects = append(skippedProjects, &projectFailure{host: host, projectName: names[i], err: e})
+		default:
+			scanned = append(scanned, results[i])
+		}
+	}
+	return scanned, nil
+}
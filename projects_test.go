@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScanWithForbiddenAuxiliaryEndpoints(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	f.addRepo("app", 10, 20)
+	f.addRepo("team/svc", 5)
+	f.forbid("/projects", "/systeminfo", "/projects/proj", "/quotas")
+	setForTest(t, &registryType, "auto")
+	setForTest(t, &withQuota, true)
+	cs := newTestClient(t, f)
+	ctx := context.Background()
+	if f.requestCount("/api/v2.0/systeminfo") != 1 {
+		t.Errorf("connectHost() didn't probe systeminfo")
+	}
+
+	projects, err := getProjects(cs, ctx)
+	if err != nil || len(projects) != 0 {
+		t.Errorf("getProjects() = %v, %v, want no projects and no error", projects, err)
+	}
+	proxy, err := isProxyCache(cs, ctx, "proj")
+	if err != nil || proxy {
+		t.Errorf("isProxyCache() = %v, %v, want false and no error", proxy, err)
+	}
+	q, err := getProjectQuota(cs, ctx, "proj")
+	if err != nil || q != nil {
+		t.Errorf("getProjectQuota() = %v, %v, want no quota and no error", q, err)
+	}
+	res, err := scanProject(cs, ctx, "proj")
+	if err != nil {
+		t.Fatalf("scanProject() error = %v", err)
+	}
+	if res.total != 35 || len(res.artifacts) != 2 {
+		t.Errorf("scanProject() total = %d of %d repositories, want 35 of 2", res.total, len(res.artifacts))
+	}
+	if res.quota != nil {
+		t.Errorf("scanProject() quota = %v, want none", res.quota)
+	}
+}
+
+func TestScanAllProjectsSkipsFailedProject(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	f.otherProjects = []string{"gone", "locked"}
+	f.addRepo("app", 10)
+	f.forbid("/projects/locked/repositories")
+	cs := newTestClient(t, f)
+	setForTest(t, &skippedProjects, nil)
+
+	results, err := scanAllProjects(cs, context.Background())
+	if err != nil {
+		t.Fatalf("scanAllProjects() error = %v", err)
+	}
+	if len(results) != 1 || results[0].projectName != "proj" || results[0].total != 10 {
+		t.Errorf("scanAllProjects() = %d results, want only proj of size 10", len(results))
+	}
+	var skipped []string
+	for _, p := range skippedProjects {
+		skipped = append(skipped, p.projectName)
+	}
+	if len(skipped) != 2 || skipped[0] != "gone" || skipped[1] != "locked" {
+		t.Errorf("skippedProjects = %v, want [gone locked]", skipped)
+	}
+}
+
+func TestScanProjectKeepsFilteredReposForLimits(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	f.addRepo("big", 100)
+	f.addRepo("app", 1, 2)
+	setForTest(t, &minTags, 2)
+	cs := newTestClient(t, f)
+
+	res, err := scanProject(cs, context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("scanProject() error = %v", err)
+	}
+	if len(res.artifacts) != 1 || res.artifacts[0].repositoryName != "proj/app" {
+		t.Errorf("scanProject() shows %d repositories, want only proj/app", len(res.artifacts))
+	}
+	if len(res.scanned) != 2 {
+		t.Errorf("scanProject() scanned %d repositories, want big and app for --fail-repo-over", len(res.scanned))
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var openMetrics bool
+
+// scanStarted is when execute began scanning, for --openmetrics scan
+// metrics.
+var scanStarted time.Time
+
+var promLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type promMetric struct {
+	name  string
+	help  string
+	value func(v *artifactsSize) int64
+}
+
+var promRepositoryMetrics = []promMetric{
+	{"harbor_repository_size_bytes", "Size of artifacts of a harbor repository.", func(v *artifactsSize) int64 { return v.artifactSize }},
+	{"harbor_repository_artifacts", "Number of artifacts of a harbor repository.", func(v *artifactsSize) int64 { return int64(v.countArtifacts) }},
+	{"harbor_repository_pull_count", "Pull count of a harbor repository.", func(v *artifactsSize) int64 { return v.pullCount }},
+}
+
+// writePrometheus writes results in prometheus text format to w, or
+// atomically to --prom-file so a textfile collector never reads it half
+// written.
+func writePrometheus(w io.Writer, results []*projectResult) (err error) {
+	if promFile == "" {
+		return renderPrometheus(w, results)
+	}
+	var buf bytes.Buffer
+	if err = renderPrometheus(&buf, results); err != nil {
+		return
+	}
+	return writeFileAtomic(promFile, buf.Bytes(), 0o644)
+}
+
+// renderPrometheus writes the prometheus text format, or with --openmetrics
+// the OpenMetrics format with a timestamp on every sample, scan duration and
+// time metrics and the closing EOF.
+func renderPrometheus(w io.Writer, results []*projectResult) (err error) {
+	ts := ""
+	now := time.Now()
+	if openMetrics {
+		ts = " " + strconv.FormatFloat(float64(now.UnixMilli())/1000, 'f', 3, 64)
+	}
+	for _, m := range promRepositoryMetrics {
+		if _, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name); err != nil {
+			return
+		}
+		for _, res := range results {
+			for _, v := range res.artifacts {
+				if _, err = fmt.Fprintf(w, "%s{%s,repository=\"%s\"} %d%s\n", m.name, promProjectLabels(res), promLabelReplacer.Replace(v.repositoryName), m.value(v), ts); err != nil {
+					return
+				}
+			}
+		}
+	}
+	if _, err = fmt.Fprint(w, "# HELP harbor_project_size_bytes Size of artifacts of a harbor project.\n# TYPE harbor_project_size_bytes gauge\n"); err != nil {
+		return
+	}
+	for _, res := range results {
+		if _, err = fmt.Fprintf(w, "harbor_project_size_bytes{%s} %d%s\n", promProjectLabels(res), res.total, ts); err != nil {
+			return
+		}
+	}
+	if !openMetrics {
+		return
+	}
+	_, err = fmt.Fprintf(w, "# HELP harbor_scan_duration_seconds Duration of the hartisize scan.\n# TYPE harbor_scan_duration_seconds gauge\nharbor_scan_duration_seconds %s%s\n"+
+		"# HELP harbor_scan_timestamp_seconds Time the hartisize scan started.\n# TYPE harbor_scan_timestamp_seconds gauge\nharbor_scan_timestamp_seconds %s%s\n# EOF\n",
+		strconv.FormatFloat(now.Sub(scanStarted).Seconds(), 'f', 3, 64), ts,
+		strconv.FormatFloat(float64(scanStarted.UnixMilli())/1000, 'f', 3, 64), ts)
+	return
+}
+
+func promProjectLabels(res *projectResult) string {
+	labels := fmt.Sprintf("project=\"%s\"", promLabelReplacer.Replace(res.projectName))
+	if res.host != "" {
+		labels = fmt.Sprintf("host=\"%s\",%s", promLabelReplacer.Replace(res.host), labels)
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/client/project"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/client/quota"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+var withQuota bool
+
+// projectQuota is the harbor storage quota of a project, hard is -1 when
+// unlimited.
+type projectQuota struct {
+	used int64
+	hard int64
+}
+
+type jsonQuota struct {
+	UsedBytes int64    `json:"used_bytes"`
+	HardBytes int64    `json:"hard_bytes"`
+	Percent   *float64 `json:"percent,omitempty"`
+}
+
+// getProjectQuota returns the quota of projectName, nil when it has none or
+// the user may not read it.
+func getProjectQuota(cs *v2client.HarborAPI, ctx context.Context, projectName string) (q *projectQuota, err error) {
+	log.Debugf("try get quota of project %s", projectName)
+	p, err := cs.Project.GetProject(ctx, project.NewGetProjectParams().WithProjectNameOrID(projectName))
+	if err != nil {
+		switch {
+		case hasStatus(err, http.StatusNotFound):
+			return nil, fmt.Errorf("%w: %s", errProjectNotFound, projectName)
+		case hasStatus(err, http.StatusForbidden):
+			log.Debugf("no access to project %s metadata, skip its quota: %v", projectName, err)
+			return nil, nil
+		}
+		return nil, classifyError(err)
+	}
+	ref := "project"
+	id := strconv.FormatInt(int64(p.Payload.ProjectID), 10)
+	res, err := cs.Quota.ListQuotas(ctx, quota.NewListQuotasParams().WithReference(&ref).WithReferenceID(&id))
+	if hasStatus(err, http.StatusForbidden) {
+		log.Debugf("no access to quotas of project %s, skip its quota: %v", projectName, err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	if len(res.Payload) == 0 {
+		return nil, nil
+	}
+	return &projectQuota{used: res.Payload[0].Used["storage"], hard: res.Payload[0].Hard["storage"]}, nil
+}
+
+// percent returns used of hard in percent, false for unlimited quota.
+func (q *projectQuota) percent() (float64, bool) {
+	if q.hard <= 0 {
+		return 0, false
+	}
+	return float64(q.used) * 100 / float64(q.hard), true
+}
+
+func (q *projectQuota) String() string {
+	if p, ok := q.percent(); ok {
+		return fmt.Sprintf("%s used of %s quota (%.0f%%)", humanArtifactSize(q.used), humanArtifactSize(q.hard), p)
+	}
+	return fmt.Sprintf("%s used of unlimited quota", humanArtifactSize(q.used))
+}
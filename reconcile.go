@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Compare summed artifact sizes with harbor quota usage of projects",
+	Long: `Compare summed artifact sizes with the storage quota usage harbor reports
+for each project. Harbor counts a blob shared by artifacts once, so the sum of
+artifact sizes is usually larger than the quota usage.`,
+	Example: `  hartisize reconcile --host https://harbor.example.com --all-projects`,
+	GroupID: "report",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		runReconcile()
+		return
+	},
+}
+
+type reconcileResult struct {
+	projectName string
+	computed    int64
+	used        int64
+}
+
+type jsonReconcile struct {
+	Project       string   `json:"project"`
+	ComputedBytes int64    `json:"computed_bytes"`
+	QuotaUsed     int64    `json:"quota_used_bytes"`
+	DeltaBytes    int64    `json:"delta_bytes"`
+	DeltaPercent  *float64 `json:"delta_percent,omitempty"`
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+}
+
+func runReconcile() {
+	prepare()
+	if outputFormat != "table" && outputFormat != "json" {
+		log.Fatalf("reconcile supports table or json output, not %q", outputFormat)
+	}
+	if len(hosts) > 1 {
+		log.Fatal("reconcile supports a single --host")
+	}
+	withQuota = true
+	ctx := context.TODO()
+	cs, err := connectHost(hosts[0])
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if projectID != 0 {
+		projectName, err = getProjectName(cs, ctx, projectID)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	var results []*projectResult
+	if allProjects {
+		results, err = scanAllProjects(cs, ctx)
+	} else {
+		var res *projectResult
+		res, err = scanProject(cs, ctx, projectName)
+		results = append(results, res)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	var reconciled []*reconcileResult
+	for _, res := range results {
+		if res.quota == nil {
+			log.Warnf("project %s has no quota usage, skipped", res.projectName)
+			continue
+		}
+		reconciled = append(reconciled, &reconcileResult{projectName: res.projectName, computed: res.total, used: res.quota.used})
+	}
+	if err = renderReconcile(os.Stdout, reconciled); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// delta returns computed minus quota usage, in bytes and in percent of the
+// usage, false when nothing is used.
+func (r *reconcileResult) delta() (bytes int64, percent float64, ok bool) {
+	bytes = r.computed - r.used
+	if r.used <= 0 {
+		return bytes, 0, false
+	}
+	return bytes, float64(bytes) * 100 / float64(r.used), true
+}
+
+func renderReconcile(w io.Writer, results []*reconcileResult) (err error) {
+	if outputFormat == "json" {
+		out := struct {
+			SchemaVersion int              `json:"schemaVersion"`
+			Projects      []*jsonReconcile `json:"projects"`
+		}{SchemaVersion: jsonSchemaVersion, Projects: make([]*jsonReconcile, 0, len(results))}
+		for _, r := range results {
+			p := &jsonReconcile{Project: r.projectName, ComputedBytes: r.computed, QuotaUsed: r.used}
+			var percent float64
+			var ok bool
+			if p.DeltaBytes, percent, ok = r.delta(); ok {
+				p.DeltaPercent = &percent
+			}
+			out.Projects = append(out.Projects, p)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Harbor artifact sizes against quota usage")
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Project", "Computed", "QuotaUsed", "Delta", "DeltaPct"})
+	for _, r := range results {
+		bytes, percent, ok := r.delta()
+		pct := "-"
+		if ok {
+			pct = fmt.Sprintf("%+.1f%%", percent)
+		}
+		delta := humanArtifactSize(bytes)
+		if bytes < 0 {
+			delta = "-" + humanArtifactSize(-bytes)
+		}
+		tw.AppendRow(table.Row{r.projectName, humanArtifactSize(r.computed), humanArtifactSize(r.used), delta, pct})
+	}
+	tw.SetColumnConfigs([]table.ColumnConfig{{Name: "DeltaPct", Align: text.AlignRight}})
+	_, err = fmt.Fprintln(w, tw.Render())
+	return
+}
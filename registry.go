@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+type registryClient struct {
+	base   *url.URL
+	client *http.Client
+	mu     sync.Mutex
+	tokens map[string]string
+	// noReferrers is set once the registry doesn't serve the referrers api
+	noReferrers atomic.Bool
+}
+
+type manifestDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type manifest struct {
+	MediaType string                `json:"mediaType"`
+	Config    *manifestDescriptor   `json:"config"`
+	Layers    []*manifestDescriptor `json:"layers"`
+	Manifests []*manifestDescriptor `json:"manifests"`
+}
+
+// newRegistryClient returns a client of the registry api of the harbor at
+// apiURL, sending requests through rt.
+func newRegistryClient(apiURL *url.URL, rt http.RoundTripper) *registryClient {
+	base := *apiURL
+	base.Path = strings.TrimSuffix(base.Path, apiBasePath)
+	return &registryClient{
+		base:   &base,
+		client: &http.Client{Transport: rt},
+		tokens: make(map[string]string),
+	}
+}
+
+func (r *registryClient) getManifest(ctx context.Context, repoName string, reference string) (m *manifest, raw []byte, err error) {
+	res, err := r.get(ctx, repoName, fmt.Sprintf("/v2/%s/manifests/%s", repoName, reference), strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("get manifest %s@%s: %s", repoName, reference, res.Status)
+	}
+	raw, err = io.ReadAll(res.Body)
+	if err != nil {
+		return
+	}
+	m = new(manifest)
+	err = json.Unmarshal(raw, m)
+	return
+}
+
+// referrers lists manifests referring to digest by the OCI referrers api,
+// errReferrersUnsupported once the registry answered it doesn't serve it.
+func (r *registryClient) referrers(ctx context.Context, repoName string, digest string) (descs []*manifestDescriptor, err error) {
+	if r.noReferrers.Load() {
+		return nil, errReferrersUnsupported
+	}
+	res, err := r.get(ctx, repoName, fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest), "application/vnd.oci.image.index.v1+json")
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		r.noReferrers.Store(true)
+		return nil, errReferrersUnsupported
+	default:
+		return nil, fmt.Errorf("get referrers of %s@%s: %s", repoName, digest, res.Status)
+	}
+	var index manifest
+	if err = json.NewDecoder(res.Body).Decode(&index); err != nil {
+		return
+	}
+	return index.Manifests, nil
+}
+
+// referrersSize sums full sizes of manifests referring to digest.
+func (r *registryClient) referrersSize(ctx context.Context, repoName string, digest string) (size int64, err error) {
+	descs, err := r.referrers(ctx, repoName, digest)
+	if err != nil {
+		return
+	}
+	seen := map[string]bool{digest: true}
+	for _, d := range descs {
+		var refSize int64
+		refSize, err = r.manifestSize(ctx, repoName, d.Digest, seen)
+		if err != nil {
+			return
+		}
+		size += refSize
+	}
+	return
+}
+
+// get sends a GET of path with pull scope of repoName, logging in once
+// when the registry asks for a token.
+func (r *registryClient) get(ctx context.Context, repoName string, path string, accept string) (res *http.Response, err error) {
+	u := *r.base
+	u.Path += path
+	scope := fmt.Sprintf("repository:%s:pull", repoName)
+	for attempt := 0; attempt < 2; attempt++ {
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", accept)
+		r.authorize(req, scope)
+		res, err = r.client.Do(req)
+		if err != nil {
+			return
+		}
+		if res.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			break
+		}
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+		if err = r.login(ctx, challenge, scope); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *registryClient) authorize(req *http.Request, scope string) {
+	r.mu.Lock()
+	token, ok := r.tokens[scope]
+	r.mu.Unlock()
+	switch {
+	case ok && token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case ok && !anonymous:
+		setCredentials(req)
+	}
+}
+
+func setCredentials(req *http.Request) {
+	if apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+		return
+	}
+	req.SetBasicAuth(username, password)
+}
+
+func (r *registryClient) login(ctx context.Context, challenge string, scope string) (err error) {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") {
+		r.mu.Lock()
+		r.tokens[scope] = ""
+		r.mu.Unlock()
+		return
+	}
+	var u *url.URL
+	u, err = url.Parse(params["realm"])
+	if err != nil {
+		return
+	}
+	q := u.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return
+	}
+	if !anonymous {
+		setCredentials(req)
+	}
+	var res *http.Response
+	res, err = r.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("get registry token for %s: %s", scope, res.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return
+	}
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	r.mu.Lock()
+	r.tokens[scope] = token
+	r.mu.Unlock()
+	return
+}
+
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for rest != "" {
+		var key string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+	return
+}
+
+// manifestSize sums the manifest itself, its config and layers, and for
+// an index the sizes of all referenced manifests, the way Harbor does.
+func (r *registryClient) manifestSize(ctx context.Context, repoName string, digest string, seen map[string]bool) (size int64, err error) {
+	if seen[digest] {
+		return
+	}
+	seen[digest] = true
+	var m *manifest
+	var raw []byte
+	m, raw, err = r.getManifest(ctx, repoName, digest)
+	if err != nil {
+		return
+	}
+	size = int64(len(raw))
+	if m.Config != nil {
+		size += m.Config.Size
+	}
+	for _, l := range m.Layers {
+		size += l.Size
+	}
+	for _, child := range m.Manifests {
+		var childSize int64
+		childSize, err = r.manifestSize(ctx, repoName, child.Digest, seen)
+		if err != nil {
+			return
+		}
+		size += childSize
+	}
+	return
+}
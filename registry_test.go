@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestManifestSizeStopsOnCycles(t *testing.T) {
+	f := newFakeHarbor(t, "proj")
+	// the index lists itself and a manifest that lists the index again
+	index := &manifest{Manifests: []*manifestDescriptor{{Digest: "sha256:image"}, {Digest: "sha256:index"}}}
+	image := &manifest{
+		Config:    &manifestDescriptor{Digest: "sha256:config", Size: 10},
+		Layers:    []*manifestDescriptor{{Digest: "sha256:layer", Size: 100}},
+		Manifests: []*manifestDescriptor{{Digest: "sha256:index"}},
+	}
+	f.manifests["sha256:index"] = index
+	f.manifests["sha256:image"] = image
+	setForTest(t, &verify, true)
+	newTestClient(t, f)
+
+	size, err := registry.manifestSize(context.Background(), "proj/app", "sha256:index", make(map[string]bool))
+	if err != nil {
+		t.Fatalf("manifestSize() error = %v", err)
+	}
+	rawIndex, _ := json.Marshal(index)
+	rawImage, _ := json.Marshal(image)
+	if want := int64(len(rawIndex)+len(rawImage)) + 110; size != want {
+		t.Errorf("manifestSize() = %d, want %d", size, want)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/client/project"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/client/retention"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var retentionArtifacts *artifactCollector
+
+var retentionCmd = &cobra.Command{
+	Use:     "retention-preview",
+	Short:   "Simulate the project tag retention policy and print space it would reclaim",
+	Example: `  hartisize retention-preview --host https://harbor.example.com --project library`,
+	GroupID: "report",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		runRetentionPreview()
+		return
+	},
+}
+
+type retentionResult struct {
+	repositoryName string
+	artifacts      int
+	deleted        int
+	reclaimed      int64
+}
+
+func init() {
+	rootCmd.AddCommand(retentionCmd)
+}
+
+func runRetentionPreview() {
+	prepare()
+	if allProjects || len(hosts) > 1 {
+		log.Fatal("retention-preview supports a single --host and --project")
+	}
+	if resume {
+		log.Warn("--resume is ignored by retention-preview, every artifact must be listed")
+		resume = false
+	}
+	ctx := context.TODO()
+	cs, err := connectHost(hosts[0])
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if projectID != 0 {
+		projectName, err = getProjectName(cs, ctx, projectID)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	policy, err := getRetentionPolicy(cs, ctx, projectName)
+	if err != nil {
+		exitWithError(err)
+	}
+	if policy == nil {
+		log.Infof("project %s has no retention policy, nothing would be deleted", projectName)
+		return
+	}
+	retentionArtifacts = &artifactCollector{}
+	res, err := scanProject(cs, ctx, projectName)
+	if err != nil {
+		exitWithError(err)
+	}
+	renderRetentionPreview(os.Stdout, res.projectName, simulateRetention(policy, projectName, retentionArtifacts.artifacts, time.Now()))
+}
+
+func getRetentionPolicy(cs *v2client.HarborAPI, ctx context.Context, projectName string) (policy *models.RetentionPolicy, err error) {
+	log.Debugf("try get retention policy of project %s", projectName)
+	res, err := cs.Project.GetProject(ctx, project.NewGetProjectParams().WithProjectNameOrID(projectName))
+	if err != nil {
+		if hasStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("%w: %s", errProjectNotFound, projectName)
+		}
+		return nil, classifyError(err)
+	}
+	if res.Payload.Metadata == nil || res.Payload.Metadata.RetentionID == nil {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(*res.Payload.Metadata.RetentionID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention id %q of project %s", *res.Payload.Metadata.RetentionID, projectName)
+	}
+	policyRes, err := cs.Retention.GetRetention(ctx, retention.NewGetRetentionParams().WithID(id))
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return policyRes.Payload, nil
+}
+
+// simulateRetention returns per repository what the policy would delete:
+// every artifact not retained by an enabled rule, except immutable ones.
+// Harbor leaves repositories out of every rule scope alone.
+func simulateRetention(policy *models.RetentionPolicy, projectName string, artifacts []*artifactEntry, now time.Time) (results []*retentionResult) {
+	byRepo := make(map[string][]*artifactEntry)
+	for _, a := range artifacts {
+		byRepo[a.repositoryName] = append(byRepo[a.repositoryName], a)
+	}
+	names := make([]string, 0, len(byRepo))
+	for name := range byRepo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var rules []*models.RetentionRule
+	for _, r := range policy.Rules {
+		if !r.Disabled {
+			rules = append(rules, r)
+		}
+	}
+	patterns := compileRulePatterns(rules)
+	for _, name := range names {
+		repoArtifacts := byRepo[name]
+		res := &retentionResult{repositoryName: name, artifacts: len(repoArtifacts)}
+		results = append(results, res)
+		retained := make(map[*artifactEntry]bool)
+		inScope := false
+		for _, rule := range rules {
+			if !matchesRepoSelectors(patterns, rule.ScopeSelectors["repository"], strings.TrimPrefix(name, projectName+"/")) {
+				continue
+			}
+			inScope = true
+			var candidates []*artifactEntry
+			for _, a := range repoArtifacts {
+				if matchesTagSelectors(patterns, rule.TagSelectors, a) {
+					candidates = append(candidates, a)
+				}
+			}
+			for _, a := range retainedByTemplate(rule, candidates, now) {
+				retained[a] = true
+			}
+		}
+		if !inScope {
+			continue
+		}
+		for _, a := range repoArtifacts {
+			if !retained[a] && !a.immutable {
+				res.deleted++
+				res.reclaimed += a.size
+			}
+		}
+	}
+	return
+}
+
+// doublestarPatterns holds the compiled selector patterns of retention
+// rules, nil for invalid ones.
+type doublestarPatterns map[string]*regexp.Regexp
+
+// compileRulePatterns compiles every selector pattern of rules once, so
+// matching a tag doesn't build its regexp again.
+func compileRulePatterns(rules []*models.RetentionRule) doublestarPatterns {
+	patterns := make(doublestarPatterns)
+	add := func(pattern string) {
+		if _, ok := patterns[pattern]; ok {
+			return
+		}
+		re, err := compileDoublestar(pattern)
+		if err != nil {
+			log.Debugf("invalid retention pattern %q: %v", pattern, err)
+		}
+		patterns[pattern] = re
+	}
+	for _, r := range rules {
+		for _, s := range r.ScopeSelectors["repository"] {
+			add(s.Pattern)
+		}
+		for _, s := range r.TagSelectors {
+			add(s.Pattern)
+		}
+	}
+	return patterns
+}
+
+// match reports whether name matches pattern, compiled by
+// compileRulePatterns.
+func (p doublestarPatterns) match(pattern string, name string) bool {
+	re := p[pattern]
+	return re != nil && re.MatchString(name)
+}
+
+func matchesRepoSelectors(patterns doublestarPatterns, selectors []models.RetentionSelector, repoName string) bool {
+	for _, s := range selectors {
+		matched := patterns.match(s.Pattern, repoName)
+		if s.Decoration == "repoExcludes" {
+			matched = !matched
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesTagSelectors(patterns doublestarPatterns, selectors []*models.RetentionSelector, a *artifactEntry) bool {
+	for _, s := range selectors {
+		if len(a.tags) == 0 {
+			var extras struct {
+				Untagged bool `json:"untagged"`
+			}
+			_ = json.Unmarshal([]byte(s.Extras), &extras)
+			if !extras.Untagged {
+				return false
+			}
+			continue
+		}
+		matched := false
+		for _, t := range a.tags {
+			matched = matched || patterns.match(s.Pattern, t)
+		}
+		if s.Decoration == "excludes" {
+			matched = !matched
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// retainedByTemplate returns candidates the rule template keeps, unknown
+// templates keep every candidate so nothing is counted as deleted.
+func retainedByTemplate(rule *models.RetentionRule, candidates []*artifactEntry, now time.Time) []*artifactEntry {
+	n := intParam(rule.Params, rule.Template)
+	latest := func(at func(a *artifactEntry) time.Time) []*artifactEntry {
+		sorted := append([]*artifactEntry(nil), candidates...)
+		sort.SliceStable(sorted, func(i, j int) bool { return at(sorted[i]).After(at(sorted[j])) })
+		if len(sorted) > n {
+			sorted = sorted[:n]
+		}
+		return sorted
+	}
+	within := func(at func(a *artifactEntry) time.Time) (kept []*artifactEntry) {
+		since := now.AddDate(0, 0, -n)
+		for _, a := range candidates {
+			if at(a).After(since) {
+				kept = append(kept, a)
+			}
+		}
+		return
+	}
+	pushed := func(a *artifactEntry) time.Time { return a.pushTime }
+	pulled := func(a *artifactEntry) time.Time { return a.pullTime }
+	switch rule.Template {
+	case "always":
+		return candidates
+	case "nothing":
+		return nil
+	case "latestPushedK":
+		return latest(pushed)
+	case "latestPulledN":
+		return latest(pulled)
+	case "latestActiveK":
+		return latest(func(a *artifactEntry) time.Time {
+			if a.pullTime.After(a.pushTime) {
+				return a.pullTime
+			}
+			return a.pushTime
+		})
+	case "nDaysSinceLastPush":
+		return within(pushed)
+	case "nDaysSinceLastPull":
+		return within(pulled)
+	}
+	log.Warnf("unknown retention template %q, its artifacts are counted as retained", rule.Template)
+	return candidates
+}
+
+func intParam(params map[string]interface{}, key string) int {
+	switch v := params[key].(type) {
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// doublestarMatch matches name against a harbor doublestar pattern: ** any
+// path, * and ? within a path segment and {a,b} alternatives.
+func doublestarMatch(pattern string, name string) bool {
+	re, err := compileDoublestar(pattern)
+	if err != nil {
+		log.Debugf("invalid retention pattern %q: %v", pattern, err)
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// compileDoublestar returns the regexp of a doublestar pattern, a comma is
+// an alternative only within braces.
+func compileDoublestar(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '{':
+			depth++
+			b.WriteString("(?:")
+		case c == '}' && depth > 0:
+			depth--
+			b.WriteString(")")
+		case c == ',' && depth > 0:
+			b.WriteString("|")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unclosed { in %q", pattern)
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func renderRetentionPreview(w io.Writer, projectName string, results []*retentionResult) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Harbor retention preview of project - %s", projectName)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Repository", "Artifacts", "Deleted", "Reclaimed"})
+	var deleted int
+	var reclaimed int64
+	for _, r := range results {
+		tw.AppendRow(table.Row{displayRepoName(projectName, r.repositoryName), r.artifacts, r.deleted, humanArtifactSize(r.reclaimed)})
+		deleted += r.deleted
+		reclaimed += r.reclaimed
+	}
+	tw.AppendFooter(table.Row{"Total", "", deleted, humanArtifactSize(reclaimed)})
+	fmt.Fprintln(w, tw.Render())
+}
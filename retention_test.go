@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"testing"
+	"time"
+)
+
+func TestDoublestarMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**", "team/app", true},
+		{"*", "team/app", false},
+		{"team/*", "team/app", true},
+		{"team/*", "team/backend/app", false},
+		{"team/**", "team/backend/app", true},
+		{"app?", "app1", true},
+		{"app?", "app/", false},
+		{"{app,web}/*", "web/v1", true},
+		{"{app,web}/*", "db/v1", false},
+		{"{app,{web,api}}", "api", true},
+		{"a,b", "a,b", true},
+		{"a,b", "a", false},
+		{"a}", "a}", true},
+		{"v1.*", "v1x2", false},
+		{"{unclosed", "unclosed", false},
+	}
+	for _, tt := range tests {
+		if got := doublestarMatch(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("doublestarMatch(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+// retentionRule returns an enabled rule of template with param n over
+// repositories matching scope and tags picked by tagSelector.
+func retentionRule(template string, n int, scope string, tagSelector *models.RetentionSelector) *models.RetentionRule {
+	return &models.RetentionRule{
+		Template:       template,
+		Params:         map[string]interface{}{template: float64(n)},
+		ScopeSelectors: map[string][]models.RetentionSelector{"repository": {{Kind: "doublestar", Decoration: "repoMatches", Pattern: scope}}},
+		TagSelectors:   []*models.RetentionSelector{tagSelector},
+	}
+}
+
+// tagsMatching selects tags matching pattern, and untagged artifacts when
+// untagged is set.
+func tagsMatching(pattern string, untagged bool) *models.RetentionSelector {
+	return &models.RetentionSelector{Kind: "doublestar", Decoration: "matches", Pattern: pattern, Extras: fmt.Sprintf(`{"untagged":%v}`, untagged)}
+}
+
+func TestSimulateRetention(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	artifacts := []*artifactEntry{
+		{repositoryName: "proj/app", tags: []string{"v1"}, size: 1, pushTime: daysAgo(30)},
+		{repositoryName: "proj/app", tags: []string{"v2"}, size: 2, pushTime: daysAgo(10)},
+		{repositoryName: "proj/app", tags: []string{"dev-1"}, size: 4, pushTime: daysAgo(3)},
+		{repositoryName: "proj/app", size: 8, pushTime: daysAgo(1)},
+		{repositoryName: "proj/web", tags: []string{"v1"}, size: 16, pushTime: daysAgo(20), immutable: true},
+		{repositoryName: "proj/web", tags: []string{"v2"}, size: 32, pushTime: daysAgo(2)},
+	}
+	disabled := retentionRule("always", 0, "**", tagsMatching("**", true))
+	disabled.Disabled = true
+	excludes := tagsMatching("dev-*", false)
+	excludes.Decoration = "excludes"
+	tests := []struct {
+		name  string
+		rules []*models.RetentionRule
+		// deleted artifacts and reclaimed size of app and web
+		want [2][2]int64
+	}{
+		{"latest pushed", []*models.RetentionRule{retentionRule("latestPushedK", 1, "**", tagsMatching("**", true))},
+			[2][2]int64{{3, 7}, {0, 0}}},
+		{"repository out of scope", []*models.RetentionRule{retentionRule("latestPushedK", 1, "app", tagsMatching("**", true))},
+			[2][2]int64{{3, 7}, {0, 0}}},
+		{"immutable kept", []*models.RetentionRule{retentionRule("nothing", 0, "web", tagsMatching("**", true))},
+			[2][2]int64{{0, 0}, {1, 32}}},
+		{"disabled rule", []*models.RetentionRule{disabled, retentionRule("nothing", 0, "**", tagsMatching("**", true))},
+			[2][2]int64{{4, 15}, {1, 32}}},
+		{"only disabled rules", []*models.RetentionRule{disabled},
+			[2][2]int64{{0, 0}, {0, 0}}},
+		{"untagged not selected", []*models.RetentionRule{retentionRule("always", 0, "app", tagsMatching("**", false))},
+			[2][2]int64{{1, 8}, {0, 0}}},
+		{"excluded tags", []*models.RetentionRule{retentionRule("always", 0, "app", excludes)},
+			[2][2]int64{{2, 12}, {0, 0}}},
+		{"days since last push", []*models.RetentionRule{retentionRule("nDaysSinceLastPush", 7, "**", tagsMatching("**", true))},
+			[2][2]int64{{2, 3}, {0, 0}}},
+		{"unknown template", []*models.RetentionRule{retentionRule("latestSomething", 1, "**", tagsMatching("**", true))},
+			[2][2]int64{{0, 0}, {0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := simulateRetention(&models.RetentionPolicy{Rules: tt.rules}, "proj", artifacts, now)
+			if len(results) != 2 || results[0].repositoryName != "proj/app" || results[1].repositoryName != "proj/web" {
+				t.Fatalf("simulateRetention() = %d results, want proj/app and proj/web", len(results))
+			}
+			for i, res := range results {
+				if got := [2]int64{int64(res.deleted), res.reclaimed}; got != tt.want[i] {
+					t.Errorf("simulateRetention() %s deletes %d of size %d, want %d of size %d", res.repositoryName, got[0], got[1], tt.want[i][0], tt.want[i][1])
+				}
+			}
+		})
+	}
+}
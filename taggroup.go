@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
+	"regexp"
+	"sort"
+)
+
+type tagGroup struct {
+	count int
+	size  int64
+}
+
+// compileTagGroupRegex returns the regex and the index of the capture
+// naming a group: the first named capture, or the first capture.
+func compileTagGroupRegex(expr string) (re *regexp.Regexp, group int, err error) {
+	re, err = regexp.Compile(expr)
+	if err != nil {
+		return
+	}
+	if re.NumSubexp() == 0 {
+		return nil, 0, fmt.Errorf("tag group regex %q has no capture group", expr)
+	}
+	group = 1
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			group = i
+			break
+		}
+	}
+	return
+}
+
+// addTagGroups counts a once in every group matched by its tags.
+func addTagGroups(groups map[string]*tagGroup, a *models.Artifact) {
+	seen := make(map[string]bool)
+	for _, t := range a.Tags {
+		m := tagGroupRegex.FindStringSubmatch(t.Name)
+		if m == nil || seen[m[tagGroupIndex]] {
+			continue
+		}
+		seen[m[tagGroupIndex]] = true
+		g := groups[m[tagGroupIndex]]
+		if g == nil {
+			g = &tagGroup{}
+			groups[m[tagGroupIndex]] = g
+		}
+		g.count++
+		g.size += a.Size
+	}
+}
+
+func sortedTagGroups(groups map[string]*tagGroup) (names []string) {
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func renderTagGroups(w io.Writer, res *projectResult) {
+	for _, v := range res.artifacts {
+		if len(v.tagGroups) == 0 {
+			continue
+		}
+		tw := table.NewWriter()
+		tw.SetStyle(tableStyle())
+		tw.SetTitle("Tag groups of repository - %s", v.repositoryName)
+		tw.Style().Title.Align = text.AlignCenter
+		tw.AppendHeader(table.Row{"Group", "Artifacts", "Size"})
+		for _, name := range sortedTagGroups(v.tagGroups) {
+			g := v.tagGroups[name]
+			tw.AppendRow(table.Row{name, g.count, humanArtifactSize(g.size)})
+		}
+		fmt.Fprintln(w, tw.Render())
+	}
+}
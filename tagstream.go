@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"io"
+	"sync"
+	"time"
+)
+
+var ndjsonPerTag bool
+
+// tagStream writes one json line per tag while repositories are scanned,
+// nil unless --ndjson-per-tag is set. Lines of concurrent repositories
+// interleave, so records are not sorted.
+var tagStream *tagStreamWriter
+
+type tagStreamWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+type jsonTagRecord struct {
+	Project    string    `json:"project"`
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	Digest     string    `json:"digest"`
+	SizeBytes  int64     `json:"size_bytes"`
+	PushTime   time.Time `json:"push_time"`
+}
+
+func newTagStream(w io.Writer) *tagStreamWriter {
+	return &tagStreamWriter{enc: json.NewEncoder(w)}
+}
+
+// add writes a record per tag of a, the first write error is kept and
+// later records are dropped.
+func (s *tagStreamWriter) add(projectName string, repoName string, a *models.Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range a.Tags {
+		if s.err != nil {
+			return
+		}
+		pushed := time.Time(t.PushTime)
+		if pushed.IsZero() {
+			pushed = time.Time(a.PushTime)
+		}
+		s.err = s.enc.Encode(&jsonTagRecord{
+			Project:    projectName,
+			Repository: repoName,
+			Tag:        t.Name,
+			Digest:     a.Digest,
+			SizeBytes:  a.Size,
+			PushTime:   pushed,
+		})
+	}
+}
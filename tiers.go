@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
+	"sort"
+)
+
+var showTiers bool
+var tierBounds []string
+
+// tierLimits are the parsed --tier-bounds, ascending.
+var tierLimits []*tierLimit
+
+type tierLimit struct {
+	name string
+	size int64
+}
+
+type sizeTier struct {
+	name  string
+	repos int
+	size  int64
+}
+
+func parseTierBounds(bounds []string) (limits []*tierLimit, err error) {
+	for _, b := range bounds {
+		var size int64
+		if size, err = parseHumanSize(b); err != nil {
+			return nil, fmt.Errorf("invalid --tier-bounds %q: %w", b, err)
+		}
+		limits = append(limits, &tierLimit{name: b, size: size})
+	}
+	sort.SliceStable(limits, func(i, j int) bool { return limits[i].size < limits[j].size })
+	for i := 1; i < len(limits); i++ {
+		if limits[i].size == limits[i-1].size {
+			return nil, fmt.Errorf("duplicate --tier-bounds %q", limits[i].name)
+		}
+	}
+	return
+}
+
+// sizeTiers buckets repositories of res by size, largest tier first. A tier
+// holds sizes from its lower bound up to, not including, the next one.
+func sizeTiers(res *projectResult, limits []*tierLimit) []*sizeTier {
+	tiers := make([]*sizeTier, len(limits)+1)
+	for i := range tiers {
+		switch {
+		case len(limits) == 0:
+			tiers[i] = &sizeTier{name: "all"}
+		case i == 0:
+			tiers[i] = &sizeTier{name: "<" + limits[0].name}
+		case i == len(limits):
+			tiers[i] = &sizeTier{name: ">=" + limits[i-1].name}
+		default:
+			tiers[i] = &sizeTier{name: limits[i-1].name + "-" + limits[i].name}
+		}
+	}
+	for _, v := range res.artifacts {
+		i := sort.Search(len(limits), func(i int) bool { return limits[i].size > v.artifactSize })
+		tiers[i].repos++
+		tiers[i].size += v.artifactSize
+	}
+	for i, j := 0, len(tiers)-1; i < j; i, j = i+1, j-1 {
+		tiers[i], tiers[j] = tiers[j], tiers[i]
+	}
+	return tiers
+}
+
+func renderTiers(w io.Writer, res *projectResult) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("%s by size tier", res.projectName)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"Tier", "Repositories", "Size"})
+	var repos int
+	var size int64
+	for _, t := range sizeTiers(res, tierLimits) {
+		tw.AppendRow(table.Row{t.name, t.repos, humanArtifactSize(t.size)})
+		repos += t.repos
+		size += t.size
+	}
+	if !noFooter {
+		tw.AppendFooter(table.Row{"Total", repos, humanArtifactSize(size)})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var topArtifacts *artifactCollector
+var topCount int
+
+var topArtifactsCmd = &cobra.Command{
+	Use:     "top-artifacts",
+	Short:   "Print the largest artifacts across all repositories",
+	Example: `  hartisize top-artifacts --host https://harbor.example.com --all-projects --top 50`,
+	GroupID: "report",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if resume {
+			log.Warn("--resume is ignored by top-artifacts, every artifact must be listed")
+			resume = false
+		}
+		topArtifacts = &artifactCollector{}
+		execute()
+		return
+	},
+}
+
+type artifactEntry struct {
+	repositoryName string
+	tags           []string
+	digest         string
+	size           int64
+	pushTime       time.Time
+	pullTime       time.Time
+	immutable      bool
+}
+
+type artifactCollector struct {
+	mu        sync.Mutex
+	artifacts []*artifactEntry
+}
+
+func init() {
+	topArtifactsCmd.Flags().IntVar(&topCount, "top", 20, "Number of artifacts to show")
+	rootCmd.AddCommand(topArtifactsCmd)
+}
+
+func (c *artifactCollector) add(repoName string, a *models.Artifact) {
+	e := &artifactEntry{
+		repositoryName: repoName,
+		digest:         a.Digest,
+		size:           a.Size,
+		pushTime:       time.Time(a.PushTime),
+		pullTime:       time.Time(a.PullTime),
+	}
+	for _, t := range a.Tags {
+		e.tags = append(e.tags, t.Name)
+		e.immutable = e.immutable || t.Immutable
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.artifacts = append(c.artifacts, e)
+}
+
+func renderTopArtifacts(w io.Writer, c *artifactCollector) {
+	sort.SliceStable(c.artifacts, func(i, j int) bool { return c.artifacts[i].size > c.artifacts[j].size })
+	top := c.artifacts
+	if topCount > 0 && len(top) > topCount {
+		top = top[:topCount]
+	}
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Largest harbor artifacts")
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{"#", "Repository", "Tags", "Digest", "Size"})
+	for i, e := range top {
+		tw.AppendRow(table.Row{i + 1, e.repositoryName, strings.Join(e.tags, ", "), e.digest, humanArtifactSize(e.size)})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/goharbor/go-client/pkg/harbor"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func newTLSConfig() (cfg *tls.Config, err error) {
+	if (clientCert == "") != (clientKey == "") {
+		return nil, fmt.Errorf("--client-cert and --client-key must be set together")
+	}
+	cfg = &tls.Config{InsecureSkipVerify: insecure}
+	if caCert != "" {
+		var pem []byte
+		pem, err = os.ReadFile(caCert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caCert)
+		}
+		cfg.RootCAs = pool
+	}
+	if clientCert != "" {
+		var cert tls.Certificate
+		cert, err = tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return
+}
+
+func newHTTPTransport() (t *http.Transport) {
+	t = http.DefaultTransport.(*http.Transport).Clone()
+	if clientTLSConfig != nil {
+		t.TLSClientConfig = clientTLSConfig
+	}
+	if connectTimeout > 0 {
+		t.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+	if maxConns > 0 {
+		t.MaxConnsPerHost = maxConns
+		t.MaxIdleConnsPerHost = maxConns
+	}
+	return
+}
+
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.base.RoundTrip(req)
+}
+
+var apiCalls atomic.Int64
+
+// budgetTransport counts requests and refuses them once --max-api-calls
+// is used up.
+type budgetTransport struct {
+	base http.RoundTripper
+	once sync.Once
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if n := apiCalls.Add(1); maxAPICalls > 0 && n > maxAPICalls {
+		t.once.Do(func() {
+			log.Warnf("made %d api calls allowed by --max-api-calls, stop scan and show partial results", maxAPICalls)
+		})
+		return nil, errAPIBudget
+	}
+	return t.base.RoundTrip(req)
+}
+
+var apiSem chan struct{}
+
+// semaphoreTransport holds a slot of apiSem from sending a request until
+// its response body is closed.
+type semaphoreTransport struct {
+	base http.RoundTripper
+}
+
+func (t *semaphoreTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case apiSem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		<-apiSem
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body}
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	once sync.Once
+}
+
+func (b *releaseBody) Close() error {
+	b.once.Do(func() { <-apiSem })
+	return b.ReadCloser.Close()
+}
+
+// dryRunTransport logs mutating requests and answers them with an empty
+// 200 response instead of sending them.
+type dryRunTransport struct {
+	base http.RoundTripper
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.base.RoundTrip(req)
+	}
+	log.Infof("dry run: would %s %s", req.Method, req.URL.Redacted())
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+// dumpTransport saves bodies of repository and artifact list responses
+// to dir, one file per request.
+type dumpTransport struct {
+	base http.RoundTripper
+	dir  string
+	seq  atomic.Int64
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	resp, err = t.base.RoundTrip(req)
+	if err != nil || !(strings.HasSuffix(req.URL.Path, "/repositories") || strings.HasSuffix(req.URL.Path, "/artifacts")) {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	name := strings.Trim(strings.TrimPrefix(req.URL.Path, apiBasePath), "/")
+	if req.URL.RawQuery != "" {
+		name += "?" + req.URL.RawQuery
+	}
+	name = fmt.Sprintf("%06d-%s.json", t.seq.Add(1), dumpNameReplacer.Replace(name))
+	if werr := os.WriteFile(filepath.Join(t.dir, name), body, 0o600); werr != nil {
+		log.Warnf("can't dump response of %s: %v", req.URL.Path, werr)
+	}
+	return
+}
+
+var dumpNameReplacer = strings.NewReplacer("/", "_", "?", "_", "&", "_", "=", "-", "%", "_")
+
+func newTransport() (rt http.RoundTripper) {
+	rt = newHTTPTransport()
+	if apiSem != nil {
+		rt = &semaphoreTransport{base: rt}
+	}
+	rt = &budgetTransport{base: rt}
+	if dryRun {
+		rt = &dryRunTransport{base: rt}
+	}
+	if len(extraHeaders) > 0 {
+		rt = &headerTransport{base: rt, headers: extraHeaders}
+	}
+	return
+}
+
+func parseHeaders(values []string) (headers http.Header, err error) {
+	headers = make(http.Header)
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid header %q, expected \"Key: Value\"", v)
+		}
+		headers.Add(key, strings.TrimSpace(value))
+	}
+	return
+}
+
+func newHarborClient(urlObj *url.URL, rt http.RoundTripper) (cs *v2client.HarborAPI, err error) {
+	if dumpResponses != "" {
+		rt = &dumpTransport{base: rt, dir: dumpResponses}
+	}
+	c := harbor.Config{
+		URL:       urlObj,
+		Transport: rt,
+	}
+	switch {
+	case apiToken != "":
+		c.AuthInfo = httptransport.BearerToken(apiToken)
+	case !anonymous:
+		c.AuthInfo = httptransport.BasicAuth(username, password)
+	}
+	cs = v2client.New(c.ToV2Config())
+	return
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLSVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		caCert   string
+		insecure bool
+		wantOK   bool
+	}{
+		{"system roots", "", false, false},
+		{"ca cert", caFile, false, true},
+		{"insecure", "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setForTest(t, &caCert, tt.caCert)
+			setForTest(t, &insecure, tt.insecure)
+			cfg, err := newTLSConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			setForTest(t, &clientTLSConfig, cfg)
+			res, err := (&http.Client{Transport: newHTTPTransport()}).Get(srv.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+			if (err == nil) != tt.wantOK {
+				t.Errorf("GET with --ca-cert %q error = %v, want ok %v", tt.caCert, err, tt.wantOK)
+			}
+		})
+	}
+}
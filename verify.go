@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/goharbor/go-client/pkg/sdk/v2.0/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"math"
+)
+
+type sizeMismatch struct {
+	digest   string
+	reported int64
+	computed int64
+}
+
+func verifyArtifactSize(ctx context.Context, oneArtifact *artifactsSize, a *models.Artifact) {
+	computed, err := registry.manifestSize(ctx, oneArtifact.repositoryName, a.Digest, make(map[string]bool))
+	if err != nil {
+		log.Warnf("can't verify size of %s@%s: %v", oneArtifact.repositoryName, a.Digest, err)
+		return
+	}
+	diff := math.Abs(float64(computed - a.Size))
+	if a.Size == 0 && computed != 0 || a.Size != 0 && diff/float64(a.Size)*100 > verifyTolerance {
+		log.Debugf("size mismatch for %s@%s: reported %d, computed %d", oneArtifact.repositoryName, a.Digest, a.Size, computed)
+		oneArtifact.mismatches = append(oneArtifact.mismatches, &sizeMismatch{
+			digest:   a.Digest,
+			reported: a.Size,
+			computed: computed,
+		})
+	}
+}
+
+func renderMismatches(w io.Writer, results []*projectResult) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle())
+	tw.SetTitle("Artifacts with size mismatch over %.1f%%", verifyTolerance)
+	tw.Style().Title.Align = text.AlignCenter
+	tw.AppendHeader(table.Row{
+		"Repository",
+		"Digest",
+		"Reported",
+		"Computed",
+		"Diff",
+	})
+	count := 0
+	for _, res := range results {
+		for _, v := range res.artifacts {
+			for _, m := range v.mismatches {
+				tw.AppendRow(table.Row{
+					v.repositoryName,
+					m.digest,
+					humanArtifactSize(m.reported),
+					humanArtifactSize(m.computed),
+					humanArtifactSize(m.computed - m.reported),
+				})
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		log.Infof("all verified artifact sizes match within %.1f%%", verifyTolerance)
+		return
+	}
+	fmt.Fprintln(w, tw.Render())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const latestReleaseURL = "https://api.github.com/repos/bac-w/harbor-get-tags-size/releases/latest"
+
+var versionCheck bool
+
+var versionCmd = &cobra.Command{
+	Use:     "version",
+	Short:   "Print version, --check compares it with the latest release",
+	Example: `  hartisize version --check`,
+	GroupID: "tool",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		fmt.Printf("hartisize version %s\n", version)
+		if !versionCheck {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		latest, err := latestRelease(ctx)
+		if err != nil {
+			log.Fatalf("can't check latest release: %v", err)
+		}
+		if compareVersions(latest, version) > 0 {
+			fmt.Printf("newer release %s is available\n", latest)
+		} else {
+			fmt.Println("up to date")
+		}
+		return
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Compare with the latest GitHub release")
+	rootCmd.AddCommand(versionCmd)
+}
+
+func latestRelease(ctx context.Context) (tag string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("releases api returned %s", res.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&release); err != nil {
+		return
+	}
+	return release.TagName, nil
+}
+
+// compareVersions compares dotted numeric versions with optional v prefix,
+// missing or non numeric parts count as zero.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na > nb {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
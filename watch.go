@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	v2client "github.com/goharbor/go-client/pkg/sdk/v2.0/client"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func watchArtifacts(cs *v2client.HarborAPI, ctx context.Context) {
+	var previous map[string]*artifactsSize
+	for {
+		res, err := scanProject(cs, ctx, projectName)
+		if err != nil {
+			log.Error(err.Error())
+		} else {
+			shown := *res
+			if deltaOnly && previous != nil {
+				shown.artifacts = changedArtifacts(previous, res.artifacts)
+			}
+			if deltaOnly && previous != nil && len(shown.artifacts) == 0 && len(shown.failed) == 0 {
+				log.Infof("no changes in project %s", projectName)
+			} else {
+				report([]*projectResult{&shown})
+			}
+			previous = make(map[string]*artifactsSize, len(res.artifacts))
+			for _, v := range res.artifacts {
+				previous[v.repositoryName] = v
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchInterval):
+		}
+	}
+}
+
+func changedArtifacts(previous map[string]*artifactsSize, current []*artifactsSize) (changed []*artifactsSize) {
+	seen := make(map[string]bool, len(current))
+	for _, v := range current {
+		seen[v.repositoryName] = true
+		p, ok := previous[v.repositoryName]
+		if !ok || p.artifactSize != v.artifactSize || p.countArtifacts != v.countArtifacts {
+			changed = append(changed, v)
+		}
+	}
+	for name := range previous {
+		if !seen[name] {
+			changed = append(changed, &artifactsSize{repositoryName: name})
+		}
+	}
+	return
+}